@@ -0,0 +1,18 @@
+package resolvable
+
+import "context"
+
+// Async returns a function that starts resolving in a new goroutine with the given context.
+// The returned channel receives exactly one Result and is then closed. It is buffered, so the goroutine
+// does not leak if the result is never received.
+func Async[T any](resolvable Ctx[T]) func(ctx context.Context) <-chan Result[T] {
+	return func(ctx context.Context) <-chan Result[T] {
+		ch := make(chan Result[T], 1)
+		go func() {
+			defer close(ch)
+			v, err := resolvable(ctx)
+			ch <- Result[T]{Value: v, Err: err}
+		}()
+		return ch
+	}
+}
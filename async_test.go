@@ -0,0 +1,37 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsync(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+
+	a := Async(Static(1))(ctx)
+	b := Async(StaticError[int](errResolve))(ctx)
+
+	res := <-a
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, res.Value)
+	_, ok := <-a
+	assert.False(t, ok, "channel is closed after the result")
+
+	res = <-b
+	require.ErrorIs(t, res.Err, errResolve)
+	assert.Zero(t, res.Value)
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		res := <-Async(Ctx[int](func(ctx context.Context) (int, error) {
+			return 0, ctx.Err()
+		}))(ctx)
+		require.ErrorIs(t, res.Err, context.Canceled)
+	})
+}
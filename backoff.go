@@ -0,0 +1,377 @@
+package resolvable
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BackOff is a retry policy that determines how long to wait between retries.
+//
+// It is compatible with the BackOff interface of github.com/cenkalti/backoff/v5, see the cenkalti
+// subpackage for an adapter.
+type BackOff interface {
+	// NextBackOff returns the duration to wait before retrying, or BackOffStop to stop retrying.
+	NextBackOff() time.Duration
+	// Reset returns the policy to its initial state.
+	Reset()
+}
+
+// BackOffStop indicates that no more retries should be attempted.
+const BackOffStop time.Duration = -1
+
+// CloneableBackOff is a BackOff that can be copied, so that one policy can configure several caches.
+//
+// A BackOff keeps the state of a retry sequence, such as the current interval, so caches clone the
+// policy in RetryOpts to retry independently of each other. All the policies in this package implement
+// it. Policies that don't are shared as they are, so implement Clone, or wrap them with
+// NewBackOffFactory.
+type CloneableBackOff interface {
+	BackOff
+	// Clone returns a copy of the policy with the same settings, in its initial state.
+	Clone() BackOff
+}
+
+// cloneBackOff clones b if it is a CloneableBackOff, and returns it as is otherwise.
+func cloneBackOff(b BackOff) BackOff {
+	if c, ok := b.(CloneableBackOff); ok {
+		return c.Clone()
+	}
+	return b
+}
+
+// lockedBackOff guards a BackOff that can't be cloned, so that caches that share it, such as the keys
+// of a KeyedCache, can use it concurrently.
+type lockedBackOff struct {
+	mu sync.Mutex
+	b  BackOff
+}
+
+func (b *lockedBackOff) NextBackOff() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.NextBackOff()
+}
+
+func (b *lockedBackOff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.b.Reset()
+}
+
+// FactoryBackOff is a CloneableBackOff that creates a new policy when cloned.
+type FactoryBackOff struct {
+	BackOff
+	newBackOff func() BackOff
+}
+
+// NewBackOffFactory creates a FactoryBackOff from a function that creates a new policy every time.
+func NewBackOffFactory(newBackOff func() BackOff) *FactoryBackOff {
+	return &FactoryBackOff{BackOff: newBackOff(), newBackOff: newBackOff}
+}
+
+// Clone returns a FactoryBackOff with a new policy.
+func (b *FactoryBackOff) Clone() BackOff {
+	return NewBackOffFactory(b.newBackOff)
+}
+
+// zeroBackoff retries immediately.
+type zeroBackoff struct{}
+
+func (zeroBackoff) NextBackOff() time.Duration { return 0 }
+
+func (zeroBackoff) Reset() {}
+
+func (b zeroBackoff) Clone() BackOff { return b }
+
+// Default values for ExponentialBackOff.
+const (
+	DefaultInitialInterval = 500 * time.Millisecond
+	DefaultMultiplier      = 1.5
+	DefaultMaxInterval     = 60 * time.Second
+)
+
+// ExponentialBackOff increases the backoff interval exponentially on every retry.
+type ExponentialBackOff struct {
+	// InitialInterval is the first interval returned after a reset.
+	InitialInterval time.Duration
+	// Multiplier is the factor by which the interval grows on every call.
+	Multiplier float64
+	// MaxInterval caps the interval. Zero means no cap.
+	MaxInterval time.Duration
+
+	current time.Duration
+}
+
+// NewExponentialBackOff creates an ExponentialBackOff with the default settings.
+func NewExponentialBackOff() *ExponentialBackOff {
+	b := &ExponentialBackOff{
+		InitialInterval: DefaultInitialInterval,
+		Multiplier:      DefaultMultiplier,
+		MaxInterval:     DefaultMaxInterval,
+	}
+	b.Reset()
+	return b
+}
+
+// NextBackOff returns the current interval and grows it by the multiplier.
+func (b *ExponentialBackOff) NextBackOff() time.Duration {
+	if b.current <= 0 {
+		b.current = b.InitialInterval
+	}
+
+	next := b.clamp(b.current)
+	b.current = b.clamp(time.Duration(float64(b.current) * b.Multiplier))
+	return next
+}
+
+// Reset returns the interval to InitialInterval.
+func (b *ExponentialBackOff) Reset() {
+	b.current = b.InitialInterval
+}
+
+// Clone returns a copy of the policy in its initial state.
+func (b *ExponentialBackOff) Clone() BackOff {
+	c := *b
+	c.Reset()
+	return &c
+}
+
+func (b *ExponentialBackOff) clamp(d time.Duration) time.Duration {
+	if b.MaxInterval > 0 && (d > b.MaxInterval || d < 0) {
+		// d < 0 means the multiplication overflowed
+		return b.MaxInterval
+	}
+	return d
+}
+
+// ConstantBackOff waits the same interval between every retry.
+type ConstantBackOff struct {
+	Interval time.Duration
+}
+
+// NewConstantBackOff creates a ConstantBackOff that waits d between retries.
+func NewConstantBackOff(d time.Duration) *ConstantBackOff {
+	return &ConstantBackOff{Interval: d}
+}
+
+// NextBackOff returns Interval.
+func (b *ConstantBackOff) NextBackOff() time.Duration {
+	return b.Interval
+}
+
+// Reset is a no-op.
+func (b *ConstantBackOff) Reset() {}
+
+// Clone returns a copy of the policy.
+func (b *ConstantBackOff) Clone() BackOff {
+	c := *b
+	return &c
+}
+
+// LinearBackOff increases the backoff interval by a fixed increment on every retry.
+type LinearBackOff struct {
+	// Initial is the first interval returned after a reset.
+	Initial time.Duration
+	// Increment is added to the interval on every call.
+	Increment time.Duration
+	// MaxInterval caps the interval. Zero means no cap.
+	MaxInterval time.Duration
+
+	n int64
+}
+
+// NewLinearBackOff creates a LinearBackOff with the given settings.
+func NewLinearBackOff(initial, increment, maxInterval time.Duration) *LinearBackOff {
+	return &LinearBackOff{Initial: initial, Increment: increment, MaxInterval: maxInterval}
+}
+
+// NextBackOff returns Initial plus Increment times the number of calls since the last reset.
+func (b *LinearBackOff) NextBackOff() time.Duration {
+	next := b.Initial + time.Duration(b.n)*b.Increment
+	if b.Increment > 0 && (next < b.Initial || b.n > math.MaxInt64/int64(b.Increment)) {
+		// clamp rather than overflow
+		next = math.MaxInt64
+	}
+	if b.MaxInterval > 0 && next >= b.MaxInterval {
+		// stop growing once capped
+		return b.MaxInterval
+	}
+	b.n++
+	return next
+}
+
+// Reset returns the interval to Initial.
+func (b *LinearBackOff) Reset() {
+	b.n = 0
+}
+
+// Clone returns a copy of the policy in its initial state.
+func (b *LinearBackOff) Clone() BackOff {
+	c := *b
+	c.Reset()
+	return &c
+}
+
+// FibonacciBackOff grows the backoff interval along the Fibonacci sequence, as a middle ground between
+// linear and exponential growth.
+type FibonacciBackOff struct {
+	// Unit is multiplied by the Fibonacci sequence 1, 1, 2, 3, 5, 8, ... to get the intervals.
+	Unit time.Duration
+	// MaxInterval caps the interval. Zero means no cap.
+	MaxInterval time.Duration
+
+	current, next time.Duration
+}
+
+// NewFibonacciBackOff creates a FibonacciBackOff with the given unit and cap.
+func NewFibonacciBackOff(unit, maxInterval time.Duration) *FibonacciBackOff {
+	b := &FibonacciBackOff{Unit: unit, MaxInterval: maxInterval}
+	b.Reset()
+	return b
+}
+
+// NextBackOff returns Unit times the next number in the Fibonacci sequence.
+func (b *FibonacciBackOff) NextBackOff() time.Duration {
+	if b.current <= 0 {
+		b.Reset()
+	}
+
+	current := b.current
+	if b.MaxInterval > 0 && current >= b.MaxInterval {
+		// stop growing once capped
+		return b.MaxInterval
+	}
+	sum := b.current + b.next
+	if sum < b.next {
+		// clamp rather than overflow
+		sum = math.MaxInt64
+	}
+	b.current, b.next = b.next, sum
+	return current
+}
+
+// Reset restarts the sequence.
+func (b *FibonacciBackOff) Reset() {
+	b.current, b.next = b.Unit, b.Unit
+}
+
+// Clone returns a copy of the policy in its initial state.
+func (b *FibonacciBackOff) Clone() BackOff {
+	c := *b
+	c.Reset()
+	return &c
+}
+
+// JitterBackOff randomizes the intervals of another BackOff to avoid retrying in lockstep.
+type JitterBackOff struct {
+	// BackOff is the policy whose intervals are randomized.
+	BackOff BackOff
+	// Factor is the maximum fraction by which an interval is randomized, e.g. 0.2 for ±20%.
+	Factor float64
+	// Source is an optional random source. Defaults to the global math/rand/v2 source.
+	// Clones share it, so it must be safe for concurrent use if they are used concurrently.
+	Source rand.Source
+
+	rand *rand.Rand
+}
+
+// NewJitterBackOff creates a JitterBackOff that randomizes the intervals of b by ±factor.
+func NewJitterBackOff(b BackOff, factor float64) *JitterBackOff {
+	return &JitterBackOff{BackOff: b, Factor: factor}
+}
+
+// NextBackOff returns the next interval of the wrapped policy with jitter applied.
+func (b *JitterBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == BackOffStop {
+		return BackOffStop
+	}
+
+	delta := b.Factor * float64(next)
+	// pick a random value in [next-delta, next+delta]
+	jittered := time.Duration(float64(next) - delta + b.float64()*2*delta)
+	if jittered < 0 {
+		return 0
+	}
+	return jittered
+}
+
+// Reset resets the wrapped policy.
+func (b *JitterBackOff) Reset() {
+	b.BackOff.Reset()
+}
+
+// Clone returns a copy of the policy with a clone of the wrapped policy.
+func (b *JitterBackOff) Clone() BackOff {
+	return &JitterBackOff{BackOff: cloneBackOff(b.BackOff), Factor: b.Factor, Source: b.Source}
+}
+
+func (b *JitterBackOff) float64() float64 {
+	if b.Source == nil {
+		return rand.Float64()
+	}
+	if b.rand == nil {
+		b.rand = rand.New(b.Source)
+	}
+	return b.rand.Float64()
+}
+
+// DecorrelatedJitterBackOff implements the decorrelated jitter algorithm, where every interval is
+// random between Base and three times the previous interval, capped at Cap.
+type DecorrelatedJitterBackOff struct {
+	// Base is the minimum interval, and the first interval after a reset is random between Base and
+	// three times Base.
+	Base time.Duration
+	// Cap caps the interval. Zero means no cap.
+	Cap time.Duration
+	// Source is an optional random source. Defaults to the global math/rand/v2 source.
+	// Clones share it, so it must be safe for concurrent use if they are used concurrently.
+	Source rand.Source
+
+	prev time.Duration
+	rand *rand.Rand
+}
+
+// NewDecorrelatedJitterBackOff creates a DecorrelatedJitterBackOff with the given base and cap.
+func NewDecorrelatedJitterBackOff(base, limit time.Duration) *DecorrelatedJitterBackOff {
+	return &DecorrelatedJitterBackOff{Base: base, Cap: limit}
+}
+
+// NextBackOff returns min(Cap, random_between(Base, previous*3)).
+func (b *DecorrelatedJitterBackOff) NextBackOff() time.Duration {
+	prev := max(b.prev, b.Base)
+	upper := prev * 3
+	if upper < prev {
+		// overflow
+		upper = prev
+	}
+
+	next := b.Base + time.Duration(b.float64()*float64(upper-b.Base))
+	if b.Cap > 0 && next > b.Cap {
+		next = b.Cap
+	}
+	b.prev = next
+	return next
+}
+
+// Reset returns the previous interval to Base.
+func (b *DecorrelatedJitterBackOff) Reset() {
+	b.prev = b.Base
+}
+
+// Clone returns a copy of the policy in its initial state.
+func (b *DecorrelatedJitterBackOff) Clone() BackOff {
+	return &DecorrelatedJitterBackOff{Base: b.Base, Cap: b.Cap, Source: b.Source, prev: b.Base}
+}
+
+func (b *DecorrelatedJitterBackOff) float64() float64 {
+	if b.Source == nil {
+		return rand.Float64()
+	}
+	if b.rand == nil {
+		b.rand = rand.New(b.Source)
+	}
+	return b.rand.Float64()
+}
@@ -0,0 +1,377 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialBackOff(t *testing.T) {
+	b := NewExponentialBackOff()
+	b.InitialInterval = time.Second
+	b.Multiplier = 2
+	b.MaxInterval = 5 * time.Second
+	b.Reset()
+
+	assert.Equal(t, time.Second, b.NextBackOff())
+	assert.Equal(t, 2*time.Second, b.NextBackOff())
+	assert.Equal(t, 4*time.Second, b.NextBackOff())
+	// capped at MaxInterval
+	assert.Equal(t, 5*time.Second, b.NextBackOff())
+	assert.Equal(t, 5*time.Second, b.NextBackOff())
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.NextBackOff())
+}
+
+func TestExponentialBackOff_Defaults(t *testing.T) {
+	b := NewExponentialBackOff()
+	assert.Equal(t, DefaultInitialInterval, b.NextBackOff())
+	assert.Equal(t, 750*time.Millisecond, b.NextBackOff())
+}
+
+func TestConstantBackOff(t *testing.T) {
+	b := NewConstantBackOff(time.Second)
+	assert.Equal(t, time.Second, b.NextBackOff())
+	assert.Equal(t, time.Second, b.NextBackOff())
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.NextBackOff())
+}
+
+func TestJitterBackOff(t *testing.T) {
+	b := &JitterBackOff{
+		BackOff: NewConstantBackOff(10 * time.Second),
+		Factor:  0.2,
+		Source:  rand.NewPCG(1, 2),
+	}
+
+	distinct := map[time.Duration]bool{}
+	for range 100 {
+		d := b.NextBackOff()
+		assert.GreaterOrEqual(t, d, 8*time.Second)
+		assert.LessOrEqual(t, d, 12*time.Second)
+		distinct[d] = true
+	}
+	assert.Greater(t, len(distinct), 1)
+
+	t.Run("seeded", func(t *testing.T) {
+		a := &JitterBackOff{BackOff: NewConstantBackOff(time.Second), Factor: 0.5, Source: rand.NewPCG(3, 4)}
+		b := &JitterBackOff{BackOff: NewConstantBackOff(time.Second), Factor: 0.5, Source: rand.NewPCG(3, 4)}
+		for range 10 {
+			assert.Equal(t, a.NextBackOff(), b.NextBackOff())
+		}
+	})
+
+	t.Run("never negative", func(t *testing.T) {
+		b := NewJitterBackOff(NewConstantBackOff(time.Second), 2)
+		for range 100 {
+			assert.GreaterOrEqual(t, b.NextBackOff(), time.Duration(0))
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		b := NewJitterBackOff(NewConstantBackOff(BackOffStop), 0.2)
+		assert.Equal(t, BackOffStop, b.NextBackOff())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		inner := &ExponentialBackOff{InitialInterval: time.Second, Multiplier: 2}
+		b := NewJitterBackOff(inner, 0)
+		assert.Equal(t, time.Second, b.NextBackOff())
+		assert.Equal(t, 2*time.Second, b.NextBackOff())
+		b.Reset()
+		assert.Equal(t, time.Second, b.NextBackOff())
+	})
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count      int
+		resolveErr = errors.New("try again")
+	)
+	b := &ExponentialBackOff{InitialInterval: time.Second, Multiplier: 2}
+	v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}), CacheOpts{
+		Retry:     true,
+		RetryOpts: RetryOpts{Backoff: b},
+		Now:       func() time.Time { return now },
+	})
+
+	value, err := v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 1, value)
+
+	// the error is cached until the backoff elapses
+	value, err = v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 1, value)
+
+	now = now.Add(time.Second)
+	value, err = v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 2, value)
+
+	// the backoff grew to two seconds
+	now = now.Add(time.Second)
+	value, err = v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 2, value)
+
+	now = now.Add(time.Second)
+	resolveErr = nil
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	// successful values are cached forever
+	now = now.Add(time.Hour)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
+
+func TestRetry_SharedBackoff(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	b := &ExponentialBackOff{InitialInterval: time.Second, Multiplier: 2}
+	opts := CacheOpts{Retry: true, RetryOpts: RetryOpts{Backoff: NewJitterBackOff(b, 0)}, Now: clock.Now}
+	newCache := func() (Ctx[int], *int) {
+		var count int
+		return Cache(func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("try again")
+		}, opts), &count
+	}
+	first, firstCount := newCache()
+	second, secondCount := newCache()
+
+	// both caches back off for a second after their first failure
+	_, _ = first(ctx)
+	_, _ = first(ctx)
+	_, _ = second(ctx)
+	clock.Add(time.Second)
+	_, _ = first(ctx)
+	_, _ = second(ctx)
+	assert.Equal(t, 2, *firstCount)
+	assert.Equal(t, 2, *secondCount)
+
+	// the policy itself is left untouched
+	assert.Equal(t, time.Second, b.NextBackOff())
+}
+
+func TestCloneBackOff(t *testing.T) {
+	for name, b := range map[string]BackOff{
+		"exponential": NewExponentialBackOff(),
+		"constant":    NewConstantBackOff(time.Second),
+		"linear":      NewLinearBackOff(time.Second, time.Second, 0),
+		"fibonacci":   NewFibonacciBackOff(time.Second, 0),
+		"jitter":      NewJitterBackOff(NewLinearBackOff(time.Second, time.Second, 0), 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			want := []time.Duration{b.NextBackOff(), b.NextBackOff(), b.NextBackOff()}
+
+			// clones start over, independently of the original
+			clone := cloneBackOff(b)
+			assert.Equal(t, want[0], clone.NextBackOff())
+			assert.Equal(t, want[1], clone.NextBackOff())
+			b.Reset()
+			assert.Equal(t, want[0], b.NextBackOff())
+			assert.Equal(t, want[2], clone.NextBackOff())
+		})
+	}
+
+	t.Run("decorrelated", func(t *testing.T) {
+		b := NewDecorrelatedJitterBackOff(time.Second, time.Minute)
+		b.NextBackOff()
+		clone := cloneBackOff(b).(*DecorrelatedJitterBackOff)
+		assert.Equal(t, time.Second, clone.prev)
+		assert.Equal(t, time.Minute, clone.Cap)
+	})
+
+	t.Run("factory", func(t *testing.T) {
+		var created int
+		b := NewBackOffFactory(func() BackOff {
+			created++
+			return NewLinearBackOff(time.Second, time.Second, 0)
+		})
+		assert.Equal(t, time.Second, b.NextBackOff())
+		clone := cloneBackOff(b)
+		assert.Equal(t, time.Second, clone.NextBackOff())
+		assert.Equal(t, 2*time.Second, b.NextBackOff())
+		assert.Equal(t, 2, created)
+	})
+}
+
+func TestRetry_MaxTries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var count int
+	v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, errors.New("resolve error")
+	}), CacheOpts{
+		Expiry:    time.Minute,
+		Retry:     true,
+		RetryOpts: RetryOpts{MaxTries: 3},
+		Now:       func() time.Time { return now },
+	})
+
+	for range 10 {
+		_, err := v(ctx)
+		require.ErrorContains(t, err, "resolve error")
+	}
+	assert.Equal(t, 3, count)
+
+	// the last error is returned until the value expires
+	value, err := v(ctx)
+	require.ErrorIs(t, err, ErrRetriesExhausted)
+	require.EqualError(t, err, "resolvable: retries exhausted: resolve error")
+	assert.Equal(t, 3, value)
+
+	// once expired, we get a fresh set of tries
+	now = now.Add(time.Minute)
+	_, err = v(ctx)
+	require.EqualError(t, err, "resolve error")
+	require.NotErrorIs(t, err, ErrRetriesExhausted)
+	for range 10 {
+		_, err := v(ctx)
+		require.ErrorContains(t, err, "resolve error")
+	}
+	assert.Equal(t, 6, count)
+
+	t.Run("unlimited", func(t *testing.T) {
+		var count int
+		v := Retry(Ctx[int](func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("resolve error")
+		}), RetryOpts{})
+
+		for range 10 {
+			_, err := v(ctx)
+			require.EqualError(t, err, "resolve error")
+		}
+		assert.Equal(t, 10, count)
+	})
+}
+
+// stopAfter is a BackOff that stops after n intervals.
+type stopAfter struct {
+	n, calls int
+}
+
+func (b *stopAfter) NextBackOff() time.Duration {
+	b.calls++
+	if b.calls >= b.n {
+		return BackOffStop
+	}
+	return 0
+}
+
+func (b *stopAfter) Reset() {
+	b.calls = 0
+}
+
+func TestRetry_BackOffStop(t *testing.T) {
+	ctx := context.Background()
+	var count int
+	v := Retry(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, errors.New("resolve error")
+	}), RetryOpts{Backoff: &stopAfter{n: 3}})
+
+	for range 10 {
+		_, err := v(ctx)
+		require.ErrorContains(t, err, "resolve error")
+	}
+	assert.Equal(t, 3, count)
+
+	_, err := v(ctx)
+	require.ErrorIs(t, err, ErrRetriesExhausted)
+}
+
+func TestDecorrelatedJitterBackOff(t *testing.T) {
+	b := &DecorrelatedJitterBackOff{Base: time.Second, Cap: time.Minute, Source: rand.NewPCG(1, 2)}
+
+	prev := b.Base
+	for range 100 {
+		d := b.NextBackOff()
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, min(prev*3, time.Minute))
+		prev = d
+	}
+
+	t.Run("seeded", func(t *testing.T) {
+		a := &DecorrelatedJitterBackOff{Base: time.Second, Source: rand.NewPCG(3, 4)}
+		b := &DecorrelatedJitterBackOff{Base: time.Second, Source: rand.NewPCG(3, 4)}
+		for range 10 {
+			assert.Equal(t, a.NextBackOff(), b.NextBackOff())
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		b := NewDecorrelatedJitterBackOff(time.Second, 0)
+		for range 20 {
+			b.NextBackOff()
+		}
+		b.Reset()
+		assert.LessOrEqual(t, b.NextBackOff(), 3*time.Second)
+	})
+}
+
+func TestFibonacciBackOff(t *testing.T) {
+	b := NewFibonacciBackOff(time.Second, 0)
+	for _, n := range []time.Duration{1, 1, 2, 3, 5, 8} {
+		assert.Equal(t, n*time.Second, b.NextBackOff())
+	}
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.NextBackOff())
+
+	t.Run("capped", func(t *testing.T) {
+		b := NewFibonacciBackOff(time.Second, 4*time.Second)
+		for _, n := range []time.Duration{1, 1, 2, 3, 4, 4} {
+			assert.Equal(t, n*time.Second, b.NextBackOff())
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		b := NewFibonacciBackOff(time.Hour, 0)
+		prev := time.Duration(0)
+		for range 200 {
+			d := b.NextBackOff()
+			assert.GreaterOrEqual(t, d, prev)
+			prev = d
+		}
+	})
+}
+
+func TestLinearBackOff(t *testing.T) {
+	b := NewLinearBackOff(time.Second, 2*time.Second, 6*time.Second)
+	for _, d := range []time.Duration{1, 3, 5, 6, 6} {
+		assert.Equal(t, d*time.Second, b.NextBackOff())
+	}
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.NextBackOff())
+
+	t.Run("overflow", func(t *testing.T) {
+		b := &LinearBackOff{Initial: time.Hour, Increment: math.MaxInt64 / 4}
+		prev := time.Duration(0)
+		for range 10 {
+			d := b.NextBackOff()
+			assert.GreaterOrEqual(t, d, prev)
+			prev = d
+		}
+	})
+}
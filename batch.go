@@ -0,0 +1,110 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMissingKey is returned by BatchResolver when the batch function succeeds without a value for a key.
+var ErrMissingKey = errors.New("resolvable: key missing from batch result")
+
+type BatchOpts struct {
+	// Window is how long to collect keys for after the first one before resolving the batch.
+	Window time.Duration
+	// MaxBatchSize resolves the batch right away once it has this many keys. Zero means unlimited.
+	MaxBatchSize int
+}
+
+// BatchResolver groups lookups of individual keys into batched calls to an upstream that supports
+// multi-get. Its Resolve method can be used as the resolvable of a KeyedCache, so that concurrent cache
+// misses are resolved together.
+//
+// BatchResolver is safe for concurrent use.
+type BatchResolver[K comparable, T any] struct {
+	resolvable func(ctx context.Context, keys []K) (map[K]T, error)
+	opts       BatchOpts
+
+	mu sync.Mutex
+	// pending is the batch collecting keys, or nil.
+	pending *batch[K, T]
+}
+
+type batch[K comparable, T any] struct {
+	ctx  context.Context
+	keys []K
+	seen map[K]struct{}
+
+	done   chan struct{}
+	values map[K]T
+	err    error
+}
+
+// NewBatchResolver creates a BatchResolver for the batch function, which returns the values of the
+// keys it has found.
+func NewBatchResolver[K comparable, T any](resolvable func(ctx context.Context, keys []K) (map[K]T, error), opts BatchOpts) *BatchResolver[K, T] {
+	return &BatchResolver[K, T]{resolvable: resolvable, opts: opts}
+}
+
+// Resolve adds key to the pending batch and waits for its value. If the batch function fails, its error
+// is returned, and if it has no value for the key, ErrMissingKey is returned.
+//
+// The batch is resolved with a context that is not cancelled along with the callers', so a cancelled
+// caller returns its context's error without failing the others.
+func (r *BatchResolver[K, T]) Resolve(ctx context.Context, key K) (T, error) {
+	r.mu.Lock()
+	b := r.pending
+	if b == nil {
+		b = &batch[K, T]{
+			ctx:  detachInfo(context.WithoutCancel(ctx)),
+			seen: make(map[K]struct{}),
+			done: make(chan struct{}),
+		}
+		r.pending = b
+		time.AfterFunc(r.opts.Window, func() {
+			r.mu.Lock()
+			if r.pending != b {
+				// already resolved because it was full
+				r.mu.Unlock()
+				return
+			}
+			r.pending = nil
+			r.mu.Unlock()
+			r.resolve(b)
+		})
+	}
+	if _, ok := b.seen[key]; !ok {
+		b.seen[key] = struct{}{}
+		b.keys = append(b.keys, key)
+	}
+	full := r.opts.MaxBatchSize > 0 && len(b.keys) >= r.opts.MaxBatchSize
+	if full {
+		r.pending = nil
+	}
+	r.mu.Unlock()
+	if full {
+		go r.resolve(b)
+	}
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+	if b.err != nil {
+		var zero T
+		return zero, b.err
+	}
+	v, ok := b.values[key]
+	if !ok {
+		return v, ErrMissingKey
+	}
+	return v, nil
+}
+
+func (r *BatchResolver[K, T]) resolve(b *batch[K, T]) {
+	b.values, b.err = r.resolvable(b.ctx, b.keys)
+	close(b.done)
+}
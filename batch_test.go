@@ -0,0 +1,133 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchResolver(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	r := NewBatchResolver(func(ctx context.Context, ids []int) (map[int]string, error) {
+		mu.Lock()
+		batches = append(batches, ids)
+		mu.Unlock()
+
+		values := make(map[int]string, len(ids))
+		for _, id := range ids {
+			if id >= 0 {
+				values[id] = fmt.Sprintf("user %d", id)
+			}
+		}
+		return values, nil
+	}, BatchOpts{Window: 20 * time.Millisecond})
+
+	resolveAll := func(ids ...int) ([]string, []error) {
+		var (
+			wg     sync.WaitGroup
+			values = make([]string, len(ids))
+			errs   = make([]error, len(ids))
+		)
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				values[i], errs[i] = r.Resolve(ctx, id)
+			}()
+		}
+		wg.Wait()
+		return values, errs
+	}
+
+	// lookups within the window are batched, and duplicates coalesced
+	values, errs := resolveAll(1, 2, 3, 2, -1)
+	assert.Equal(t, []string{"user 1", "user 2", "user 3", "user 2", ""}, values)
+	for _, err := range errs[:4] {
+		assert.NoError(t, err)
+	}
+	require.ErrorIs(t, errs[4], ErrMissingKey)
+	require.Len(t, batches, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3, -1}, batches[0])
+
+	// a later lookup starts a new batch
+	value, err := r.Resolve(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "user 4", value)
+	assert.Len(t, batches, 2)
+
+	t.Run("max batch size", func(t *testing.T) {
+		var (
+			mu    sync.Mutex
+			sizes []int
+		)
+		r := NewBatchResolver(func(ctx context.Context, ids []int) (map[int]int, error) {
+			mu.Lock()
+			sizes = append(sizes, len(ids))
+			mu.Unlock()
+			values := make(map[int]int, len(ids))
+			for _, id := range ids {
+				values[id] = id
+			}
+			return values, nil
+		}, BatchOpts{Window: time.Hour, MaxBatchSize: 2})
+
+		var wg sync.WaitGroup
+		for i := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := r.Resolve(ctx, i)
+				assert.NoError(t, err)
+				assert.Equal(t, i, value)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, []int{2, 2}, sizes)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errBatch := errors.New("batch error")
+		r := NewBatchResolver(func(ctx context.Context, ids []int) (map[int]int, error) {
+			return nil, errBatch
+		}, BatchOpts{Window: time.Millisecond})
+
+		_, err := r.Resolve(ctx, 1)
+		require.ErrorIs(t, err, errBatch)
+	})
+
+	t.Run("keyed cache", func(t *testing.T) {
+		var calls int
+		r := NewBatchResolver(func(ctx context.Context, ids []int) (map[int]int, error) {
+			calls++
+			values := make(map[int]int, len(ids))
+			for _, id := range ids {
+				values[id] = id * 2
+			}
+			return values, nil
+		}, BatchOpts{Window: 20 * time.Millisecond})
+		c := NewKeyedCache(r.Resolve, KeyedCacheOpts{})
+
+		var wg sync.WaitGroup
+		for i := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := c.Resolve(ctx, i)
+				assert.NoError(t, err)
+				assert.Equal(t, i*2, value)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,678 @@
+package resolvable
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CacheOpts struct {
+	// Expiry is the duration after which the value is considered expired.
+	Expiry time.Duration
+	// ErrorExpiry is the duration after which an error is considered expired, if it is cached rather
+	// than retried. Defaults to Expiry.
+	ErrorExpiry time.Duration
+	// Retry indicates whether to retry the resolvable if it returns an error.
+	Retry bool
+	// RetryOpts configures retries when Retry is set.
+	RetryOpts RetryOpts
+	// Now sets a custom time.Now function.
+	Now func() time.Time
+	// Logger receives diagnostic messages. Nothing is logged when nil.
+	Logger Logger
+	// Name prefixes the messages sent to Logger, to tell caches apart.
+	Name string
+	// ExpiryJitter is the maximum random duration added to Expiry for every successful value, so that
+	// values cached at the same time don't all expire together. Errors are not jittered.
+	ExpiryJitter time.Duration
+	// JitterSource is an optional random source for ExpiryJitter. Defaults to the global math/rand/v2
+	// source. It is shared by all keys of a KeyedCache, so it must be safe for concurrent use there.
+	JitterSource rand.Source
+	// StaleWhileRevalidate is the duration after expiry during which the expired value is still
+	// returned while it is resolved again in the background. Only successful values are served stale.
+	// If the background resolution fails, the stale value is served until the window elapses, after
+	// which the value is resolved synchronously.
+	StaleWhileRevalidate time.Duration
+	// OnError is called whenever the resolvable fails, including attempts that are retried and
+	// background resolutions. It runs inline and must not block for long.
+	OnError func(ctx context.Context, err error)
+	// OnResolve is called whenever a value is returned successfully, with fromCache set for cache hits.
+	// For fresh resolutions, including background resolutions, d is how long the resolvable took.
+	// It runs inline and must not block for long.
+	OnResolve func(ctx context.Context, d time.Duration, fromCache bool)
+	// ServeDoneContexts returns cached values even if the context passed to Resolve is already done.
+	// By default, Resolve returns the context's error right away instead.
+	ServeDoneContexts bool
+	// Metrics receives the hits, misses, errors and resolution durations of the cache. Retries are
+	// counted as errors, and background resolutions are observed but are not misses.
+	Metrics Metrics
+}
+
+func (o *CacheOpts) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+func (o *CacheOpts) metrics() Metrics {
+	if o.Metrics != nil {
+		return o.Metrics
+	}
+	return noopMetrics{}
+}
+
+// Cache is a wrapper around a resolvable value that allows for expiry.
+//
+// Cache is safe for concurrent use. Cache hits do not take a lock, while resolving holds a mutex so
+// that concurrent callers wait for the fresh value rather than resolving it again.
+func Cache[T any](resolvable Ctx[T], opts CacheOpts) Ctx[T] {
+	return NewCache(resolvable, opts).Resolve
+}
+
+// Warm resolves the value once, e.g. at startup to populate the caches of the resolvable before the
+// first request. See Cached.Warm.
+func Warm[T any](resolvable Ctx[T], ctx context.Context) (T, error) {
+	return resolvable(ctx)
+}
+
+// NewCache is like Cache, but returns the underlying Cached value for more control over the cache.
+func NewCache[T any](resolvable Ctx[T], opts CacheOpts) *Cached[T] {
+	return NewTypedCache(resolvable, TypedCacheOpts[T]{CacheOpts: opts})
+}
+
+// TypedCacheOpts extends CacheOpts with options that depend on the type of the value.
+type TypedCacheOpts[T any] struct {
+	CacheOpts
+	// ExpiryFunc returns how long a successfully resolved value is cached for, e.g. until a token
+	// expires. It takes precedence over Expiry and ExpiryJitter for successful values. A result of zero
+	// or less expires the value immediately.
+	ExpiryFunc func(value T) time.Duration
+	// ExpiresAt returns when a successfully resolved value expires, e.g. the exp claim of a JWT.
+	// It takes precedence over ExpiryFunc, Expiry, and ExpiryJitter for successful values.
+	// A zero time means the value never expires.
+	ExpiresAt func(value T) time.Time
+	// RefreshBefore expires values derived from ExpiresAt or ExpiryFunc this long before they actually
+	// expire, e.g. so that a token never expires mid-request. Combined with a StaleWhileRevalidate of at
+	// most RefreshBefore, tokens are rotated in the background without ever serving an expired one.
+	RefreshBefore time.Duration
+	// CacheIf reports whether a resolution should be cached. If it returns false, the value and error
+	// are returned to the caller, and the next call resolves again. Defaults to caching everything.
+	CacheIf func(value T, err error) bool
+	// Store persists successful values under StoreKey, in addition to keeping them in memory.
+	// Before resolving, an unexpired value in the store is used instead. Defaults to memory only.
+	Store Store[T]
+	// StoreKey is the key of the value in Store.
+	StoreKey string
+	// OnExpire is called once with every successfully resolved value that expired, before it is
+	// resolved again, e.g. to release resources tied to it. With StaleWhileRevalidate, a stale value is
+	// still served while it is resolved in the background, so OnExpire is called once it is replaced.
+	// It runs inline, with the cache locked, and must not block for long.
+	OnExpire func(old T)
+	// CloseOnEvict closes successfully resolved values that implement io.Closer once they are evicted,
+	// that is replaced by another resolution or dropped by Invalidate, e.g. to not leak connections.
+	// Values are not closed if they are replaced by themselves. Errors from Close are passed to OnError.
+	CloseOnEvict bool
+
+	// keepGood and maxStaleness are set by GracefulCache, see GracefulCacheOpts.
+	keepGood     bool
+	maxStaleness time.Duration
+}
+
+// TypedCache is like Cache, with options that depend on the type of the value.
+func TypedCache[T any](resolvable Ctx[T], opts TypedCacheOpts[T]) Ctx[T] {
+	return NewTypedCache(resolvable, opts).Resolve
+}
+
+// NewTypedCache is like NewCache, with options that depend on the type of the value.
+func NewTypedCache[T any](resolvable Ctx[T], opts TypedCacheOpts[T]) *Cached[T] {
+	if opts.Retry {
+		resolvable = attempt(resolvable, opts.RetryOpts)
+		if opts.RetryOpts.Backoff != nil {
+			// retry independently of other caches configured with the same policy
+			opts.RetryOpts.Backoff = cloneBackOff(opts.RetryOpts.Backoff)
+		}
+	}
+	background, cancel := context.WithCancel(context.Background())
+	return &Cached[T]{resolvable: resolvable, opts: opts, background: background, cancel: cancel}
+}
+
+// Cached is a cached resolvable value created by NewCache.
+type Cached[T any] struct {
+	opts       TypedCacheOpts[T]
+	resolvable Ctx[T]
+	// entry is the last resolution, or nil if it has never resolved.
+	// Cache hits only load it, without taking the lock.
+	entry atomic.Pointer[cacheEntry[T]]
+	// stats is updated atomically.
+	stats cacheStats
+	// refreshes coalesces concurrent calls to Refresh.
+	refreshes flight[T]
+
+	// mu serializes resolutions and guards failures.
+	mu sync.Mutex
+	// failures is the number of consecutive failed attempts.
+	failures int
+	// firstFailure is when the first of the consecutive failed attempts resolved.
+	firstFailure time.Time
+	// rand is created from opts.JitterSource on first use. It is guarded by mu.
+	rand *rand.Rand
+	// skipStore is set by Invalidate so that the next resolution doesn't load the invalidated value
+	// from the store. It is guarded by mu.
+	skipStore bool
+
+	// background is cancelled by Close to stop background resolutions.
+	background context.Context
+	cancel     context.CancelFunc
+	// closeMu guards closed, and adding to running once closed.
+	closeMu sync.Mutex
+	closed  bool
+	// running tracks the background resolutions.
+	running sync.WaitGroup
+	// evicted is set by evict, after which replaced values are no longer closed.
+	evicted atomic.Bool
+}
+
+type cacheEntry[T any] struct {
+	value      T
+	err        error
+	resolvedAt time.Time
+	// attempts is the number of consecutive attempts it took to resolve the entry.
+	attempts int
+	// nextResolve is the time after which the value must be resolved again.
+	// The zero value means the value never expires.
+	nextResolve time.Time
+	// revalidating is set once a background resolution of the stale value has started.
+	revalidating atomic.Bool
+	// expireCalled is set once OnExpire has been called with the value.
+	expireCalled atomic.Bool
+	// held is set if resolving the value failed and the previous value was kept, see GracefulCache.
+	held bool
+}
+
+// Resolve returns the cached value, resolving it if it has expired.
+// If ctx was created by WithForceRefresh, the value is resolved again even if it has not expired.
+// If ctx is already done, its error is returned unless CacheOpts.ServeDoneContexts is set. A value is
+// never resolved with a done context, even if it is done while waiting for another resolution.
+func (e *Cached[T]) Resolve(ctx context.Context) (T, error) {
+	if !e.opts.ServeDoneContexts && ctx.Err() != nil {
+		// don't do any work for a caller that is gone
+		var zero T
+		return zero, ctx.Err()
+	}
+	force := isForceRefresh(ctx)
+	entry := e.entry.Load()
+	if !force && !e.expired(entry) {
+		return e.hit(ctx, entry)
+	}
+	if !force && e.stale(entry) {
+		// a copy, so that entry itself doesn't escape on the hot path
+		stale := entry
+		if e.goBackground(ctx, func(ctx context.Context) { e.revalidate(ctx, stale) }, &stale.revalidating) {
+			return e.hit(ctx, entry)
+		}
+		// closed, resolve it synchronously instead
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// another caller may have resolved the value while we were waiting for the lock
+	entry = e.entry.Load()
+	if !force && !e.expired(entry) && (e.opts.ServeDoneContexts || ctx.Err() == nil) {
+		return e.hit(ctx, entry)
+	}
+	if err := ctx.Err(); err != nil {
+		// the caller gave up while waiting, resolving would only fail and count against the retries
+		var zero T
+		return zero, err
+	}
+	old := entry
+	if old != nil && e.expired(old) && !e.opts.keepGood {
+		e.expire(old)
+	}
+	if !force {
+		if entry := e.load(ctx); entry != nil {
+			e.replace(ctx, entry)
+			return e.hit(ctx, entry)
+		}
+	}
+
+	if force {
+		e.stats.refreshes.Add(1)
+	} else {
+		e.stats.misses.Add(1)
+		e.opts.metrics().IncMiss()
+	}
+	start := time.Now()
+	entry = e.resolveEntry(ctx)
+	if old != nil && e.expired(old) && e.opts.keepGood && !entry.held {
+		// only expired once it is actually replaced
+		e.expire(old)
+	}
+	e.replace(ctx, entry)
+	e.save(ctx, entry)
+	e.debugf("resolvable: resolved (err: %v), next resolve at %v", entry.err, entry.nextResolve)
+	e.onResolve(ctx, time.Since(start), false, entry.err)
+	if info := infoFrom(ctx); info != nil {
+		info.report(ResolveInfo{ResolvedAt: entry.resolvedAt, Attempts: entry.attempts})
+	}
+	return entry.value, entry.err
+}
+
+type forceRefreshKey struct{}
+
+// WithForceRefresh returns a context that makes caches resolve the value again instead of returning
+// the cached value, and store the result for other callers. It only applies to calls made with the
+// returned context, and is safe to use concurrently with other callers of the same cache.
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func isForceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+// hit returns a cached entry.
+func (e *Cached[T]) hit(ctx context.Context, entry *cacheEntry[T]) (T, error) {
+	e.stats.hits.Add(1)
+	e.opts.metrics().IncHit()
+	e.onResolve(ctx, 0, true, entry.err)
+	if info := infoFrom(ctx); info != nil {
+		info.report(ResolveInfo{FromCache: true, ResolvedAt: entry.resolvedAt, Attempts: entry.attempts})
+	}
+	return entry.value, entry.err
+}
+
+// resolveEntry resolves a new entry. mu must be held.
+func (e *Cached[T]) resolveEntry(ctx context.Context) *cacheEntry[T] {
+	var nested *infoSink
+	if infoFrom(ctx) != nil {
+		nested = &infoSink{}
+		ctx = withInfoSink(ctx, nested)
+	}
+	value, err := e.resolve(ctx)
+
+	attempts := e.failures + 1
+	if nested != nil {
+		if info := nested.finish(); info.Attempts > 0 {
+			// a retry loop inside the cache reported how many attempts it took
+			attempts = e.failures + info.Attempts
+		}
+	}
+	nextResolve, err := e.next(value, err)
+	if err != nil && e.opts.keepGood {
+		if good := e.entry.Load(); good != nil && good.err == nil &&
+			(e.opts.maxStaleness <= 0 || e.opts.now().Sub(good.resolvedAt) <= e.opts.maxStaleness) {
+			// keep the good value until the next attempt
+			return &cacheEntry[T]{
+				value:       good.value,
+				resolvedAt:  good.resolvedAt,
+				attempts:    attempts,
+				nextResolve: nextResolve,
+				held:        true,
+			}
+		}
+	}
+	return &cacheEntry[T]{
+		value:       value,
+		err:         err,
+		resolvedAt:  e.opts.now(),
+		attempts:    attempts,
+		nextResolve: nextResolve,
+	}
+}
+
+// Warm resolves the value if it has not been resolved yet or has expired, so that the next call to
+// Resolve is a cache hit. It returns the resolution error, if any.
+//
+// To keep the value warm in the background, use Refreshing(cached.Resolve, interval), or call Warm
+// with a context created by WithForceRefresh on an interval.
+func (e *Cached[T]) Warm(ctx context.Context) error {
+	_, err := e.Resolve(ctx)
+	return err
+}
+
+// Refresh resolves the value again even if it has not expired, stores it, and returns it.
+// Concurrent calls share a single resolution. Like WithForceRefresh, but coalesced.
+//
+// The resolution runs with a context that is not cancelled along with the caller's, so a cancelled
+// caller returns its context's error without failing the others.
+func (e *Cached[T]) Refresh(ctx context.Context) (T, error) {
+	return e.refreshes.do(ctx, func(ctx context.Context) (T, error) {
+		return e.Resolve(WithForceRefresh(ctx))
+	})
+}
+
+// Peek returns the cached value and when it was resolved, without resolving it even if it has expired.
+// ok is false if the value has never resolved or the last resolution failed.
+func (e *Cached[T]) Peek() (value T, ok bool, resolvedAt time.Time) {
+	entry := e.entry.Load()
+	if entry == nil || entry.err != nil {
+		return value, false, time.Time{}
+	}
+	return entry.value, true, entry.resolvedAt
+}
+
+// Invalidate clears the cached value, so that the next call to Resolve resolves it again rather than
+// loading it from the Store.
+// If a resolution is in flight, Invalidate waits for it and discards its result.
+func (e *Cached[T]) Invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replace(context.Background(), nil)
+	e.skipStore = e.opts.Store != nil
+	e.failures = 0
+	if e.opts.Retry {
+		e.opts.RetryOpts.backoff().Reset()
+	}
+}
+
+// goBackground runs fn in the background with a copy of ctx that is only cancelled by Close, unless
+// the cache is closed or started was already set. It reports whether the cache is open.
+func (e *Cached[T]) goBackground(ctx context.Context, fn func(ctx context.Context), started *atomic.Bool) bool {
+	e.closeMu.Lock()
+	defer e.closeMu.Unlock()
+	if e.closed {
+		return false
+	}
+	if !started.CompareAndSwap(false, true) {
+		return true
+	}
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		ctx, cancel := context.WithCancel(detachInfo(context.WithoutCancel(ctx)))
+		defer cancel()
+		defer context.AfterFunc(e.background, cancel)()
+		fn(ctx)
+	}()
+	return true
+}
+
+// Close stops the background resolutions of stale values and waits for them to finish. Once closed,
+// stale values are resolved synchronously instead. Close is safe to call more than once.
+func (e *Cached[T]) Close() error {
+	e.closeMu.Lock()
+	e.closed = true
+	e.closeMu.Unlock()
+
+	e.cancel()
+	e.running.Wait()
+	return nil
+}
+
+// revalidate resolves the stale entry in the background and replaces it if successful.
+func (e *Cached[T]) revalidate(ctx context.Context, stale *cacheEntry[T]) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.entry.Load() != stale {
+		// already resolved by someone else
+		return
+	}
+
+	e.stats.refreshes.Add(1)
+	start := time.Now()
+	value, err := e.resolve(ctx)
+	if err != nil {
+		// keep serving the stale value
+		e.debugf("resolvable: background resolve failed: %v", err)
+		return
+	}
+	nextResolve, _ := e.next(value, nil)
+	entry := &cacheEntry[T]{value: value, resolvedAt: e.opts.now(), nextResolve: nextResolve}
+	e.replace(ctx, entry)
+	e.expire(stale)
+	e.save(ctx, entry)
+	e.debugf("resolvable: resolved in the background, next resolve at %v", entry.nextResolve)
+	e.onResolve(ctx, time.Since(start), false, nil)
+}
+
+// load returns an unexpired entry from the store, or nil. mu must be held.
+func (e *Cached[T]) load(ctx context.Context) *cacheEntry[T] {
+	if e.opts.Store == nil || e.skipStore {
+		return nil
+	}
+	value, expiry, ok, err := e.opts.Store.Get(ctx, e.opts.StoreKey)
+	if err != nil {
+		e.debugf("resolvable: failed to load %q from the store: %v", e.opts.StoreKey, err)
+		return nil
+	}
+	if !ok || (!expiry.IsZero() && !e.opts.now().Before(expiry)) {
+		return nil
+	}
+	return &cacheEntry[T]{value: value, resolvedAt: e.opts.now(), attempts: 1, nextResolve: expiry}
+}
+
+// save stores a successful entry in the store. mu must be held.
+func (e *Cached[T]) save(ctx context.Context, entry *cacheEntry[T]) {
+	if e.opts.Store == nil || entry.err != nil || e.expired(entry) {
+		// e.g. CacheIf skipped caching it
+		return
+	}
+	e.skipStore = false
+	if err := e.opts.Store.Set(ctx, e.opts.StoreKey, entry.value, entry.nextResolve); err != nil {
+		e.debugf("resolvable: failed to save %q to the store: %v", e.opts.StoreKey, err)
+	}
+}
+
+func (e *Cached[T]) resolve(ctx context.Context) (T, error) {
+	start := time.Now()
+	v, err := e.resolvable(ctx)
+	e.opts.metrics().ObserveDuration(time.Since(start))
+	if err != nil {
+		e.stats.errors.Add(1)
+		e.opts.metrics().IncError()
+		if e.opts.OnError != nil {
+			e.opts.OnError(ctx, err)
+		}
+	}
+	return v, err
+}
+
+// CacheStats are counters of how a cache has been used since it was created.
+type CacheStats struct {
+	// Hits is the number of calls that returned a cached value, including stale values and errors.
+	Hits uint64
+	// Misses is the number of calls that resolved the value because it was missing or expired.
+	Misses uint64
+	// Errors is the number of resolutions that failed, including background resolutions.
+	Errors uint64
+	// Refreshes is the number of resolutions of values that were still usable, either in the background
+	// with StaleWhileRevalidate or forced with WithForceRefresh.
+	Refreshes uint64
+}
+
+type cacheStats struct {
+	hits, misses, errors, refreshes atomic.Uint64
+}
+
+// Stats returns the usage counters of the cache. It is safe to call concurrently with Resolve.
+func (e *Cached[T]) Stats() CacheStats {
+	return CacheStats{
+		Hits:      e.stats.hits.Load(),
+		Misses:    e.stats.misses.Load(),
+		Errors:    e.stats.errors.Load(),
+		Refreshes: e.stats.refreshes.Load(),
+	}
+}
+
+// onResolve calls the OnResolve hook if the value was returned successfully.
+func (e *Cached[T]) onResolve(ctx context.Context, d time.Duration, fromCache bool, err error) {
+	if err == nil && e.opts.OnResolve != nil {
+		e.opts.OnResolve(ctx, d, fromCache)
+	}
+}
+
+// next returns the time at which a value resolved with err expires, and the error to cache, which
+// wraps err with ErrRetriesExhausted if retrying gave up.
+func (e *Cached[T]) next(value T, err error) (time.Time, error) {
+	if e.opts.Retry {
+		if err != nil && e.opts.RetryOpts.retryable(err) {
+			e.failures++
+			now := e.opts.now()
+			if e.failures == 1 {
+				e.firstFailure = now
+			}
+			if !e.opts.RetryOpts.exceeded(e.failures, e.firstFailure, now) {
+				if wait := e.opts.RetryOpts.backoff().NextBackOff(); wait != BackOffStop {
+					// try again once the backoff elapses
+					return now.Add(wait), err
+				}
+			}
+			// out of tries
+			err = exhausted(err)
+		}
+		// cache the value or error like any other
+		e.failures = 0
+		e.opts.RetryOpts.backoff().Reset()
+	}
+
+	if e.opts.CacheIf != nil && !e.opts.CacheIf(value, err) {
+		// expire immediately
+		return e.opts.now(), err
+	}
+	if err == nil && e.opts.ExpiresAt != nil {
+		expiresAt := e.opts.ExpiresAt(value)
+		if expiresAt.IsZero() {
+			return expiresAt, nil
+		}
+		return expiresAt.Add(-e.opts.RefreshBefore), nil
+	}
+	if err == nil && e.opts.ExpiryFunc != nil {
+		return e.opts.now().Add(max(e.opts.ExpiryFunc(value)-e.opts.RefreshBefore, 0)), nil
+	}
+
+	expiry := e.opts.Expiry
+	if err != nil && e.opts.ErrorExpiry > 0 {
+		expiry = e.opts.ErrorExpiry
+	}
+	if expiry <= 0 {
+		// cache forever
+		return time.Time{}, err
+	}
+	if err == nil && e.opts.ExpiryJitter > 0 {
+		expiry += e.jitter()
+	}
+	return e.opts.now().Add(expiry), err
+}
+
+// jitter returns a random duration in [0, ExpiryJitter]. mu must be held.
+func (e *Cached[T]) jitter() time.Duration {
+	n := int64(e.opts.ExpiryJitter) + 1
+	if e.opts.JitterSource == nil {
+		return time.Duration(rand.Int64N(n))
+	}
+	if e.rand == nil {
+		e.rand = rand.New(e.opts.JitterSource)
+	}
+	return time.Duration(e.rand.Int64N(n))
+}
+
+// replace makes entry the current entry and evicts the previous one. mu must be held.
+func (e *Cached[T]) replace(ctx context.Context, entry *cacheEntry[T]) {
+	old := e.entry.Swap(entry)
+	if e.evicted.Load() {
+		// values resolved after the cache was evicted belong to their callers
+		return
+	}
+	e.close(ctx, old, entry)
+}
+
+// evict drops the cache, e.g. when a KeyedCache evicts its key. With CloseOnEvict, the value it holds is
+// closed in the background, without waiting for a resolution in flight, whose value is left to its
+// callers.
+func (e *Cached[T]) evict() {
+	e.evicted.Store(true)
+	if held := e.entry.Load(); held != nil && e.entry.CompareAndSwap(held, nil) {
+		// otherwise, a resolution replaced and closed it first
+		go e.close(context.Background(), held, nil)
+	}
+}
+
+// close closes the value of old if CloseOnEvict is set, unless it is replaced by itself.
+func (e *Cached[T]) close(ctx context.Context, old, entry *cacheEntry[T]) {
+	if !e.opts.CloseOnEvict || old == nil || old.err != nil {
+		return
+	}
+	closer, ok := any(old.value).(io.Closer)
+	if !ok {
+		return
+	}
+	if entry != nil && entry.err == nil && sameValue(old.value, entry.value) {
+		return
+	}
+	if err := closer.Close(); err != nil && e.opts.OnError != nil {
+		e.opts.OnError(ctx, err)
+	}
+}
+
+// sameValue reports whether a and b are equal, if they are comparable.
+func sameValue[T any](a, b T) bool {
+	va, vb := reflect.ValueOf(any(a)), reflect.ValueOf(any(b))
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	return va.Type() == vb.Type() && va.Comparable() && va.Equal(vb)
+}
+
+// expire calls OnExpire with the value of the expired entry, unless it failed or was already expired.
+func (e *Cached[T]) expire(entry *cacheEntry[T]) {
+	if e.opts.OnExpire != nil && entry.err == nil && entry.expireCalled.CompareAndSwap(false, true) {
+		e.opts.OnExpire(entry.value)
+	}
+}
+
+func (e *Cached[T]) debugf(format string, args ...any) {
+	if e.opts.Logger == nil {
+		return
+	}
+	if e.opts.Name != "" {
+		format = "%s: " + format
+		args = append([]any{e.opts.Name}, args...)
+	}
+	e.opts.Logger.Debugf(format, args...)
+}
+
+// dropExpired reports whether the value has expired and is not being resolved, e.g. so that a
+// KeyedCache can drop the cache. Values that were never resolved, or may still be served stale, are
+// kept. OnExpire is called with the value, since it will not be resolved again.
+func (e *Cached[T]) dropExpired() bool {
+	if !e.mu.TryLock() {
+		// being resolved
+		return false
+	}
+	defer e.mu.Unlock()
+	entry := e.entry.Load()
+	if entry == nil || !e.expired(entry) || e.stale(entry) {
+		return false
+	}
+	e.expire(entry)
+	return true
+}
+
+// stale reports whether an expired entry may still be served while it is revalidated.
+func (e *Cached[T]) stale(entry *cacheEntry[T]) bool {
+	if entry == nil || entry.err != nil || entry.nextResolve.IsZero() || e.opts.StaleWhileRevalidate <= 0 {
+		return false
+	}
+	return e.opts.now().Before(entry.nextResolve.Add(e.opts.StaleWhileRevalidate))
+}
+
+func (e *Cached[T]) expired(entry *cacheEntry[T]) bool {
+	if entry == nil {
+		// if we have never resolved, pretend it is expired
+		return true
+	}
+
+	if entry.nextResolve.IsZero() {
+		// cache forever
+		return false
+	}
+
+	return !e.opts.now().Before(entry.nextResolve)
+}
@@ -0,0 +1,766 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	c := NewCache(func(ctx context.Context) (int, error) {
+		return int(count.Add(1)), nil
+	}, CacheOpts{Expiry: time.Hour})
+
+	value, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	c.Invalidate()
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := c.Resolve(ctx)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				c.Invalidate()
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestCached_Peek(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count      int
+		resolveErr error
+	)
+	c := NewCache(func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}, CacheOpts{
+		Expiry: time.Minute,
+		Retry:  true,
+		Now:    func() time.Time { return now },
+	})
+
+	_, ok, _ := c.Peek()
+	assert.False(t, ok)
+
+	_, err := c.Resolve(ctx)
+	require.NoError(t, err)
+
+	// peeking never resolves, even once expired
+	now = now.Add(time.Hour)
+	value, ok, resolvedAt := c.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, now.Add(-time.Hour), resolvedAt)
+	assert.Equal(t, 1, count)
+
+	resolveErr = errors.New("resolve error")
+	_, err = c.Resolve(ctx)
+	require.Error(t, err)
+	_, ok, _ = c.Peek()
+	assert.False(t, ok)
+}
+
+func TestCache_ErrorExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count      int
+		resolveErr error
+	)
+	v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}), CacheOpts{
+		Expiry:      time.Minute,
+		ErrorExpiry: time.Second,
+		Now:         func() time.Time { return now },
+	})
+
+	resolveErr = errors.New("resolve error")
+	_, err := v(ctx)
+	require.EqualError(t, err, "resolve error")
+
+	// the error is cached for ErrorExpiry
+	now = now.Add(500 * time.Millisecond)
+	value, err := v(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 1, value)
+
+	now = now.Add(500 * time.Millisecond)
+	resolveErr = nil
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	// successes are cached for Expiry
+	now = now.Add(59 * time.Second)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	now = now.Add(time.Second)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
+
+func TestCache_ForceRefresh(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	v := Cache(func(ctx context.Context) (int, error) {
+		return int(count.Add(1)), nil
+	}, CacheOpts{Expiry: time.Hour})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	value, err = v(WithForceRefresh(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	// the refreshed value is stored for other callers
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		clock := newFakeClock()
+		var count atomic.Int32
+		v := Cache(func(ctx context.Context) (int, error) {
+			return int(count.Add(1)), nil
+		}, CacheOpts{Expiry: time.Minute, StaleWhileRevalidate: time.Hour, Now: clock.Now})
+
+		_, err := v(ctx)
+		require.NoError(t, err)
+		clock.Add(time.Minute)
+
+		// a stale value is not served to a forced call
+		value, err := v(WithForceRefresh(ctx))
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+}
+
+func TestCached_Warm(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var count atomic.Int32
+	c := NewCache(func(ctx context.Context) (int, error) {
+		return int(count.Add(1)), nil
+	}, CacheOpts{Expiry: time.Minute, Now: clock.Now})
+
+	require.NoError(t, c.Warm(ctx))
+	value, ok, _ := c.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// already warm
+	require.NoError(t, c.Warm(ctx))
+	assert.EqualValues(t, 1, count.Load())
+
+	clock.Add(time.Minute)
+	require.NoError(t, c.Warm(ctx))
+	assert.EqualValues(t, 2, count.Load())
+
+	t.Run("error", func(t *testing.T) {
+		errResolve := errors.New("resolve error")
+		c := NewCache(StaticError[int](errResolve), CacheOpts{Retry: true})
+		require.ErrorIs(t, c.Warm(ctx), errResolve)
+	})
+
+	t.Run("package level", func(t *testing.T) {
+		var count int
+		v := Once(func(ctx context.Context) (int, error) {
+			count++
+			return count, nil
+		})
+		value, err := Warm(v, ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestCache_ExpiryJitter(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	start := clock.Now()
+
+	distinct := map[time.Time]bool{}
+	for i := range 50 {
+		c := NewCache(Static(1), CacheOpts{
+			Expiry:       time.Minute,
+			ExpiryJitter: 10 * time.Second,
+			JitterSource: rand.NewPCG(1, uint64(i)),
+			Now:          clock.Now,
+		})
+		require.NoError(t, c.Warm(ctx))
+
+		next := c.entry.Load().nextResolve
+		assert.False(t, next.Before(start.Add(time.Minute)))
+		assert.False(t, next.After(start.Add(time.Minute+10*time.Second)))
+		distinct[next] = true
+	}
+	assert.Greater(t, len(distinct), 1)
+
+	t.Run("errors", func(t *testing.T) {
+		c := NewCache(StaticError[int](errors.New("resolve error")), CacheOpts{
+			Expiry:       time.Minute,
+			ExpiryJitter: 10 * time.Second,
+			Now:          clock.Now,
+		})
+		require.Error(t, c.Warm(ctx))
+		assert.Equal(t, clock.Now().Add(time.Minute), c.entry.Load().nextResolve)
+	})
+}
+
+func TestCache_ExpiryFunc(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	type token struct {
+		n         int
+		expiresIn time.Duration
+	}
+	var (
+		count      int
+		resolveErr error
+	)
+	v := TypedCache(func(ctx context.Context) (token, error) {
+		count++
+		return token{n: count, expiresIn: time.Duration(count) * time.Minute}, resolveErr
+	}, TypedCacheOpts[token]{
+		CacheOpts: CacheOpts{Expiry: time.Hour, ErrorExpiry: time.Second, Now: clock.Now},
+		ExpiryFunc: func(t token) time.Duration {
+			return t.expiresIn
+		},
+	})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value.n)
+
+	// the first token expires after a minute, rather than Expiry
+	clock.Add(59 * time.Second)
+	value, _ = v(ctx)
+	assert.Equal(t, 1, value.n)
+	clock.Add(time.Second)
+	value, _ = v(ctx)
+	assert.Equal(t, 2, value.n)
+
+	// and the second after two
+	clock.Add(time.Minute)
+	value, _ = v(ctx)
+	assert.Equal(t, 2, value.n)
+	clock.Add(time.Minute)
+	resolveErr = errors.New("resolve error")
+	_, err = v(ctx)
+	require.Error(t, err)
+
+	// errors use ErrorExpiry
+	clock.Add(time.Second)
+	resolveErr = nil
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, value.n)
+}
+
+func TestCache_CacheIf(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count   int
+		results = [][]string{nil, {}, {"a"}, {"b"}}
+	)
+	v := TypedCache(func(ctx context.Context) ([]string, error) {
+		res := results[count]
+		count++
+		return res, nil
+	}, TypedCacheOpts[[]string]{
+		CacheIf: func(value []string, err error) bool {
+			return len(value) > 0
+		},
+	})
+
+	// empty results are returned but not cached
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	for range 2 {
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, value)
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestCached_Stats(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var resolveErr error
+	c := NewCache(func(ctx context.Context) (int, error) {
+		return 1, resolveErr
+	}, CacheOpts{Expiry: time.Minute, ErrorExpiry: time.Second, Now: clock.Now})
+	assert.Equal(t, CacheStats{}, c.Stats())
+
+	for range 3 {
+		_, _ = c.Resolve(ctx)
+	}
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, c.Stats())
+
+	_, _ = c.Resolve(WithForceRefresh(ctx))
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, Refreshes: 1}, c.Stats())
+
+	clock.Add(time.Minute)
+	resolveErr = errors.New("resolve error")
+	_, _ = c.Resolve(ctx)
+	_, _ = c.Resolve(ctx)
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 2, Errors: 1, Refreshes: 1}, c.Stats())
+}
+
+func TestCached_Refresh(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count   atomic.Int32
+		release = make(chan struct{})
+	)
+	c := NewCache(func(ctx context.Context) (int, error) {
+		n := count.Add(1)
+		if n > 1 {
+			<-release
+		}
+		return int(n), nil
+	}, CacheOpts{})
+
+	value, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// concurrent refreshes share a single resolution
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Refresh(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, value)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 2, count.Load())
+
+	// the refreshed value is stored
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
+
+func TestCache_ExpiresAt(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	start := clock.Now()
+	type token struct {
+		n   int
+		exp time.Time
+	}
+	var count int
+	v := TypedCache(func(ctx context.Context) (token, error) {
+		count++
+		return token{n: count, exp: start.Add(time.Duration(count) * time.Minute)}, nil
+	}, TypedCacheOpts[token]{
+		CacheOpts: CacheOpts{Expiry: time.Hour, Now: clock.Now},
+		ExpiresAt: func(t token) time.Time {
+			return t.exp
+		},
+	})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value.n)
+
+	// served until the absolute expiry of the first token
+	clock.Add(time.Minute - time.Nanosecond)
+	value, _ = v(ctx)
+	assert.Equal(t, 1, value.n)
+	clock.Add(time.Nanosecond)
+	value, _ = v(ctx)
+	assert.Equal(t, 2, value.n)
+
+	// the second token expires two minutes after the start, rather than an hour from now
+	clock.Add(time.Minute)
+	value, _ = v(ctx)
+	assert.Equal(t, 3, value.n)
+}
+
+func TestCache_RefreshBefore(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var count atomic.Int32
+	expiresIn := 10 * time.Minute
+	fn := func(ctx context.Context) (time.Time, error) {
+		count.Add(1)
+		return clock.Now().Add(expiresIn), nil
+	}
+
+	c := NewTypedCache(fn, TypedCacheOpts[time.Time]{
+		CacheOpts:     CacheOpts{Now: clock.Now},
+		ExpiresAt:     func(exp time.Time) time.Time { return exp },
+		RefreshBefore: time.Minute,
+	})
+	_, err := c.Resolve(ctx)
+	require.NoError(t, err)
+
+	clock.Add(9*time.Minute - time.Nanosecond)
+	_, _ = c.Resolve(ctx)
+	assert.EqualValues(t, 1, count.Load())
+
+	// a value about to expire is refreshed
+	clock.Add(time.Nanosecond)
+	exp, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count.Load())
+	assert.Equal(t, clock.Now().Add(expiresIn), exp)
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		var count atomic.Int32
+		c := NewTypedCache(func(ctx context.Context) (time.Time, error) {
+			count.Add(1)
+			return clock.Now().Add(expiresIn), nil
+		}, TypedCacheOpts[time.Time]{
+			CacheOpts:     CacheOpts{StaleWhileRevalidate: time.Minute, Now: clock.Now},
+			ExpiresAt:     func(exp time.Time) time.Time { return exp },
+			RefreshBefore: time.Minute,
+		})
+		first, err := c.Resolve(ctx)
+		require.NoError(t, err)
+
+		// the near-expiry value is still served while it is refreshed in the background
+		clock.Add(9*time.Minute + 30*time.Second)
+		exp, err := c.Resolve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, first, exp)
+		assert.True(t, clock.Now().Before(exp))
+		assert.Eventually(t, func() bool {
+			value, _, _ := c.Peek()
+			return value.After(first)
+		}, time.Second, time.Millisecond)
+		assert.EqualValues(t, 2, count.Load())
+	})
+}
+
+func TestCached_Close(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var (
+		count   atomic.Int32
+		started = make(chan struct{})
+	)
+	c := NewCache(func(ctx context.Context) (int, error) {
+		n := int(count.Add(1))
+		if n == 2 {
+			// the background resolution runs until it is cancelled
+			close(started)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return n, nil
+	}, CacheOpts{Expiry: time.Minute, StaleWhileRevalidate: time.Hour, Now: clock.Now})
+
+	_, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	clock.Add(time.Minute)
+
+	// the stale value is served while resolving in the background
+	value, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	<-started
+
+	// closing cancels and waits for the background resolution
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+
+	// once closed, stale values are resolved synchronously
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
+
+func TestCache_OnExpire(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var (
+		count   atomic.Int32
+		expired []int
+	)
+	fn := func(ctx context.Context) (int, error) {
+		n := int(count.Add(1))
+		if n == 3 {
+			return n, errors.New("resolve error")
+		}
+		return n, nil
+	}
+	c := NewTypedCache(fn, TypedCacheOpts[int]{
+		CacheOpts: CacheOpts{Expiry: time.Minute, Now: clock.Now},
+		OnExpire:  func(old int) { expired = append(expired, old) },
+	})
+
+	_, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	_, _ = c.Resolve(ctx)
+	assert.Empty(t, expired)
+
+	// called once per expiry, before resolving again
+	clock.Add(time.Minute)
+	value, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	_, _ = c.Resolve(ctx)
+	assert.Equal(t, []int{1}, expired)
+
+	// expired errors are not reported
+	clock.Add(time.Minute)
+	_, err = c.Resolve(ctx)
+	require.Error(t, err)
+	clock.Add(time.Minute)
+	_, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, expired)
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		var (
+			count   atomic.Int32
+			expired atomic.Int32
+		)
+		c := NewTypedCache(func(ctx context.Context) (int, error) {
+			return int(count.Add(1)), nil
+		}, TypedCacheOpts[int]{
+			CacheOpts: CacheOpts{Expiry: time.Minute, StaleWhileRevalidate: time.Hour, Now: clock.Now},
+			OnExpire: func(old int) {
+				assert.Equal(t, 1, old)
+				expired.Add(1)
+			},
+		})
+		_, err := c.Resolve(ctx)
+		require.NoError(t, err)
+
+		// the stale value is only expired once it is replaced
+		clock.Add(time.Minute)
+		value, err := c.Resolve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		require.NoError(t, c.Close())
+		assert.EqualValues(t, 1, expired.Load())
+		assert.EqualValues(t, 2, count.Load())
+	})
+}
+
+type testCloser struct {
+	id     int
+	closed atomic.Int32
+	err    error
+}
+
+func (c *testCloser) Close() error {
+	c.closed.Add(1)
+	return c.err
+}
+
+func TestCache_CloseOnEvict(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	errClose := errors.New("close error")
+	var (
+		values []*testCloser
+		reuse  bool
+		errs   []error
+	)
+	c := NewTypedCache(func(ctx context.Context) (*testCloser, error) {
+		if !reuse || len(values) == 0 {
+			values = append(values, &testCloser{id: len(values) + 1})
+		}
+		return values[len(values)-1], nil
+	}, TypedCacheOpts[*testCloser]{
+		CacheOpts: CacheOpts{
+			Expiry:  time.Minute,
+			Now:     clock.Now,
+			OnError: func(ctx context.Context, err error) { errs = append(errs, err) },
+		},
+		CloseOnEvict: true,
+	})
+
+	first, err := c.Resolve(ctx)
+	require.NoError(t, err)
+
+	// closed once replaced
+	clock.Add(time.Minute)
+	second, err := c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, first.closed.Load())
+	assert.Zero(t, second.closed.Load())
+
+	// but not when replaced by itself
+	reuse = true
+	_, err = c.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, second.closed.Load())
+
+	// invalidating evicts too, and close errors go to OnError without failing
+	second.err = errClose
+	c.Invalidate()
+	assert.EqualValues(t, 1, second.closed.Load())
+	assert.Equal(t, []error{errClose}, errs)
+	assert.EqualValues(t, 1, first.closed.Load())
+
+	t.Run("keyed", func(t *testing.T) {
+		var values sync.Map
+		c := NewTypedKeyedCache(func(ctx context.Context, key int) (*testCloser, error) {
+			v := &testCloser{id: key}
+			values.Store(key, v)
+			return v, nil
+		}, TypedKeyedCacheOpts[int, *testCloser]{
+			TypedCacheOpts: TypedCacheOpts[*testCloser]{CloseOnEvict: true},
+			MaxEntries:     1,
+		})
+
+		first, err := c.Resolve(ctx, 1)
+		require.NoError(t, err)
+		_, err = c.Resolve(ctx, 2)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return first.closed.Load() == 1 }, time.Second, time.Millisecond)
+
+		c.Invalidate(2)
+		second, _ := values.Load(2)
+		assert.EqualValues(t, 1, second.(*testCloser).closed.Load())
+	})
+
+	t.Run("keyed in flight", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		c := NewTypedKeyedCache(func(ctx context.Context, key int) (*testCloser, error) {
+			if key == 1 {
+				close(started)
+				<-release
+			}
+			return &testCloser{id: key}, nil
+		}, TypedKeyedCacheOpts[int, *testCloser]{
+			TypedCacheOpts: TypedCacheOpts[*testCloser]{CloseOnEvict: true},
+			MaxEntries:     1,
+		})
+
+		slow := make(chan *testCloser)
+		go func() {
+			v, err := c.Resolve(ctx, 1)
+			assert.NoError(t, err)
+			slow <- v
+		}()
+		<-started
+		// evicts key 1 while it is being resolved
+		_, err := c.Resolve(ctx, 2)
+		require.NoError(t, err)
+		close(release)
+
+		// the value returned to the caller of key 1 is not closed
+		first := <-slow
+		time.Sleep(10 * time.Millisecond)
+		assert.Zero(t, first.closed.Load())
+	})
+}
+
+func TestCache_DoneContext(t *testing.T) {
+	fn, count := Counting(Static(1))
+	v := Cache(fn, CacheOpts{Expiry: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// neither resolved nor served from the cache
+	_, err := v(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, count())
+	_, err = v(context.Background())
+	require.NoError(t, err)
+	_, err = v(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	t.Run("serve done contexts", func(t *testing.T) {
+		v := Cache(fn, CacheOpts{Expiry: time.Hour, ServeDoneContexts: true})
+		_, err := v(context.Background())
+		require.NoError(t, err)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	})
+}
+
+func TestCache_DoneWhileWaiting(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c := NewCache(func(ctx context.Context) (int, error) {
+		if calls.Add(1) == 1 {
+			<-release
+			return 0, errors.New("resolve error")
+		}
+		return 2, nil
+	}, CacheOpts{Retry: true, RetryOpts: RetryOpts{MaxTries: 2}})
+
+	// hold the lock with a slow first attempt
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Resolve(context.Background())
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		close(release)
+	}()
+	_, err := c.Resolve(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	<-done
+	assert.EqualValues(t, 1, calls.Load())
+
+	// the waiter didn't use up a try
+	value, err := c.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
@@ -0,0 +1,51 @@
+package resolvable
+
+import (
+	"context"
+	"time"
+)
+
+// CallOption changes the behavior of a single call made with ResolveWith.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	forceRefresh bool
+	timeout      time.Duration
+}
+
+// ForceRefresh makes caches resolve the value again for this call, see WithForceRefresh.
+func ForceRefresh() CallOption {
+	return func(o *callOptions) {
+		o.forceRefresh = true
+	}
+}
+
+// CallTimeout bounds this call to d, see ResolveTimeout.
+func CallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// ResolveWith resolves the value with call options that only apply to this call, e.g. to force one
+// call to skip the cache without creating another resolvable. Without options, it is the same as
+// calling v.
+func (v Ctx[T]) ResolveWith(ctx context.Context, opts ...CallOption) (T, error) {
+	if len(opts) == 0 {
+		return v(ctx)
+	}
+
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.forceRefresh {
+		ctx = WithForceRefresh(ctx)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	return v(ctx)
+}
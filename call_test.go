@@ -0,0 +1,47 @@
+package resolvable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCtx_ResolveWith(t *testing.T) {
+	ctx := context.Background()
+	fn, count := Counting(func(ctx context.Context) (time.Duration, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0, nil
+		}
+		return time.Until(deadline), nil
+	})
+	v := Cache(fn, CacheOpts{Expiry: time.Hour})
+
+	_, err := v.ResolveWith(ctx)
+	require.NoError(t, err)
+	_, err = v.ResolveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count())
+
+	// options only apply to the call they are passed to
+	left, err := v.ResolveWith(ctx, ForceRefresh(), CallTimeout(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count())
+	assert.Greater(t, left, time.Duration(0))
+	assert.LessOrEqual(t, left, time.Minute)
+
+	left, err = v.ResolveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count())
+	assert.LessOrEqual(t, left, time.Minute)
+
+	t.Run("allocations", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = v.ResolveWith(ctx)
+		})
+		assert.Zero(t, allocs)
+	})
+}
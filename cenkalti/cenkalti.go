@@ -0,0 +1,58 @@
+// Package cenkalti adapts the policies of github.com/cenkalti/backoff/v5 for use with resolvable.
+//
+// It is a separate module so that users of the resolvable module do not depend on backoff.
+package cenkalti
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/kamaln7/resolvable"
+)
+
+// BackOffAdapter wraps a backoff.BackOff as a resolvable.BackOff.
+type BackOffAdapter struct {
+	BackOff backoff.BackOff
+
+	// mu guards BackOff, which is shared by the clones of custom policies.
+	mu sync.Mutex
+}
+
+var _ resolvable.CloneableBackOff = (*BackOffAdapter)(nil)
+
+// NewBackOffAdapter creates a BackOffAdapter for b.
+func NewBackOffAdapter(b backoff.BackOff) *BackOffAdapter {
+	return &BackOffAdapter{BackOff: b}
+}
+
+// NextBackOff returns the next interval of the wrapped policy, mapping backoff.Stop to
+// resolvable.BackOffStop.
+func (b *BackOffAdapter) NextBackOff() time.Duration {
+	b.mu.Lock()
+	next := b.BackOff.NextBackOff()
+	b.mu.Unlock()
+	if next == backoff.Stop {
+		return resolvable.BackOffStop
+	}
+	return next
+}
+
+// Reset resets the wrapped policy.
+func (b *BackOffAdapter) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.BackOff.Reset()
+}
+
+// Clone returns an adapter for a copy of the wrapped policy if it is a *backoff.ExponentialBackOff.
+// Other policies of the backoff package are stateless, and custom ones can't be copied, so the adapter
+// itself is returned and shared by the clones, which is safe for concurrent use.
+func (b *BackOffAdapter) Clone() resolvable.BackOff {
+	if exp, ok := b.BackOff.(*backoff.ExponentialBackOff); ok {
+		c := *exp
+		c.Reset()
+		return NewBackOffAdapter(&c)
+	}
+	return b
+}
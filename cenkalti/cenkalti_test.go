@@ -0,0 +1,81 @@
+package cenkalti
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/kamaln7/resolvable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackOffAdapter(t *testing.T) {
+	exp := backoff.NewExponentialBackOff()
+	exp.InitialInterval = time.Second
+	exp.Multiplier = 2
+	exp.RandomizationFactor = 0
+	b := NewBackOffAdapter(exp)
+	b.Reset()
+
+	assert.Equal(t, time.Second, b.NextBackOff())
+	assert.Equal(t, 2*time.Second, b.NextBackOff())
+	b.Reset()
+	assert.Equal(t, time.Second, b.NextBackOff())
+
+	t.Run("clone", func(t *testing.T) {
+		b.Reset()
+		b.NextBackOff()
+		clone := b.Clone()
+		assert.Equal(t, time.Second, clone.NextBackOff())
+		assert.Equal(t, 2*time.Second, b.NextBackOff())
+	})
+
+	t.Run("clone custom", func(t *testing.T) {
+		// custom policies are shared by the clones, run with -race
+		b := NewBackOffAdapter(&countingBackOff{})
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				clone := b.Clone()
+				for range 10 {
+					clone.NextBackOff()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 80, b.BackOff.(*countingBackOff).calls)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		b := NewBackOffAdapter(&backoff.StopBackOff{})
+		assert.Equal(t, resolvable.BackOffStop, b.NextBackOff())
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var count int
+		v := resolvable.RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("resolve error")
+		}, resolvable.RetryOpts{Backoff: NewBackOffAdapter(&backoff.StopBackOff{})})
+
+		_, err := v(context.Background())
+		require.ErrorIs(t, err, resolvable.ErrRetriesExhausted)
+		assert.Equal(t, 1, count)
+	})
+}
+
+// countingBackOff is a custom backoff.BackOff.
+type countingBackOff struct{ calls int }
+
+func (b *countingBackOff) NextBackOff() time.Duration {
+	b.calls++
+	return 0
+}
+
+func (b *countingBackOff) Reset() { b.calls = 0 }
@@ -0,0 +1,15 @@
+module github.com/kamaln7/resolvable/cenkalti
+
+go 1.24.3
+
+require (
+	github.com/cenkalti/backoff/v5 v5.0.3
+	github.com/kamaln7/resolvable v0.0.0-20261015020731-5e0242bf99b7
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,137 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker while the circuit is open.
+var ErrCircuitOpen = errors.New("resolvable: circuit open")
+
+type CircuitOpts struct {
+	// FailureThreshold is the number of consecutive failures after which the circuit opens. Defaults to 1.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before a probe is allowed through.
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of consecutive successful probes needed to close the circuit. Defaults to 1.
+	HalfOpenProbes int
+	// Now sets a custom time.Now function.
+	Now func() time.Time
+}
+
+func (o *CircuitOpts) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops calling a failing resolvable. After opts.FailureThreshold consecutive failures the
+// circuit opens, and ErrCircuitOpen is returned immediately without resolving. Once opts.OpenDuration
+// elapses, probes are let through one at a time: a failed probe opens the circuit again, and
+// opts.HalfOpenProbes successful probes close it. Panics are counted as failures and are not recovered.
+//
+// CircuitBreaker is safe for concurrent use.
+func CircuitBreaker[T any](resolvable Ctx[T], opts CircuitOpts) Ctx[T] {
+	c := &circuit{CircuitOpts: opts}
+	return func(ctx context.Context) (T, error) {
+		if !c.allow() {
+			var zero T
+			return zero, ErrCircuitOpen
+		}
+
+		panicked := true
+		defer func() {
+			if panicked {
+				// a panicking probe must not keep the circuit half-open
+				c.record(errPanicked)
+			}
+		}()
+		v, err := resolvable(ctx)
+		panicked = false
+		c.record(err)
+		return v, err
+	}
+}
+
+// errPanicked is recorded by CircuitBreaker when the resolvable panics.
+var errPanicked = errors.New("resolvable: panicked")
+
+type circuit struct {
+	CircuitOpts
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	// successes is the number of consecutive successful probes while half-open.
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+// allow reports whether a call may go through.
+func (c *circuit) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case circuitOpen:
+		if c.now().Sub(c.openedAt) < c.OpenDuration {
+			return false
+		}
+		c.state = circuitHalfOpen
+		c.successes = 0
+		fallthrough
+	case circuitHalfOpen:
+		if c.probing {
+			// only one probe at a time
+			return false
+		}
+		c.probing = true
+	}
+	return true
+}
+
+func (c *circuit) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == circuitHalfOpen {
+		c.probing = false
+		if err != nil {
+			c.open()
+			return
+		}
+		c.successes++
+		if c.successes >= max(c.HalfOpenProbes, 1) {
+			c.state = circuitClosed
+			c.failures = 0
+		}
+		return
+	}
+
+	if err == nil {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= max(c.FailureThreshold, 1) {
+		c.open()
+	}
+}
+
+func (c *circuit) open() {
+	c.state = circuitOpen
+	c.openedAt = c.now()
+	c.failures = 0
+}
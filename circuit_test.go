@@ -0,0 +1,101 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count      int
+		resolveErr = errors.New("resolve error")
+	)
+	v := CircuitBreaker(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}), CircuitOpts{
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+		HalfOpenProbes:   2,
+		Now:              func() time.Time { return now },
+	})
+
+	for range 3 {
+		_, err := v(ctx)
+		require.EqualError(t, err, "resolve error")
+	}
+
+	// the circuit is open
+	value, err := v(ctx)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 0, value)
+	assert.Equal(t, 3, count)
+
+	// a failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	_, err = v(ctx)
+	require.EqualError(t, err, "resolve error")
+	_, err = v(ctx)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 4, count)
+
+	// successful probes close the circuit
+	now = now.Add(time.Minute)
+	resolveErr = nil
+	for i := range 2 {
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 5+i, value)
+	}
+
+	// a single failure does not open a closed circuit
+	resolveErr = errors.New("resolve error")
+	_, err = v(ctx)
+	require.EqualError(t, err, "resolve error")
+	resolveErr = nil
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, value)
+}
+
+func TestCircuitBreaker_PanickingProbe(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count   int
+		doPanic bool
+	)
+	v := CircuitBreaker(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		if doPanic {
+			panic("probe panic")
+		}
+		return count, errors.New("resolve error")
+	}), CircuitOpts{
+		OpenDuration: time.Minute,
+		Now:          func() time.Time { return now },
+	})
+
+	_, err := v(ctx)
+	require.EqualError(t, err, "resolve error")
+
+	// the panicking probe opens the circuit again instead of blocking further probes
+	now = now.Add(time.Minute)
+	doPanic = true
+	assert.PanicsWithValue(t, "probe panic", func() { _, _ = v(ctx) })
+	_, err = v(ctx)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	doPanic = false
+	_, err = v(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,409 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoResolvables is returned by combinators such as Fallback and Race that are called without any
+// resolvables.
+var ErrNoResolvables = errors.New("resolvable: no resolvables")
+
+// Fallback tries each resolvable in order and returns the first successful result.
+// If all of them fail, the zero value is returned with the errors joined using errors.Join.
+// Once the context is done, the remaining resolvables are skipped. Without any resolvables,
+// ErrNoResolvables is returned.
+func Fallback[T any](resolvables ...Ctx[T]) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		if len(resolvables) == 0 {
+			var zero T
+			return zero, ErrNoResolvables
+		}
+		var errs []error
+		for _, resolvable := range resolvables {
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, err)
+				break
+			}
+
+			v, err := resolvable(ctx)
+			if err == nil {
+				return v, nil
+			}
+			errs = append(errs, err)
+		}
+
+		var zero T
+		return zero, errors.Join(errs...)
+	}
+}
+
+// Default returns fallback with a nil error if the resolvable fails.
+//
+// Place it inside a Cache to cache the fallback like any other value, or outside to keep resolving
+// the resolvable on every call until it succeeds.
+func Default[T any](resolvable Ctx[T], fallback T) Ctx[T] {
+	return DefaultFunc(resolvable, func(error) T { return fallback })
+}
+
+// DefaultFunc is like Default, but builds the fallback from the error.
+func DefaultFunc[T any](resolvable Ctx[T], fallback func(err error) T) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			return fallback(err), nil
+		}
+		return v, nil
+	}
+}
+
+// Tee calls sink with the outcome of every resolution, e.g. for auditing or shadow writes, and then
+// returns the result unchanged. sink runs inline after the resolution and must not block for long.
+func Tee[T any](resolvable Ctx[T], sink func(ctx context.Context, v T, err error)) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		sink(ctx, v, err)
+		return v, err
+	}
+}
+
+// Map transforms the resolved value using fn.
+// If the resolvable fails, fn is not called and the zero value is returned with the error.
+func Map[T, U any](resolvable Ctx[T], fn func(T) (U, error)) Ctx[U] {
+	return func(ctx context.Context) (U, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(v)
+	}
+}
+
+// MapError passes errors from the resolvable through fn, e.g. to wrap or normalize them.
+// If fn returns nil, the failure becomes a success and the zero value is returned with a nil error,
+// not the value that came with the original error.
+func MapError[T any](resolvable Ctx[T], fn func(error) error) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err == nil {
+			return v, nil
+		}
+		var zero T
+		return zero, fn(err)
+	}
+}
+
+// BiMap combines Map and MapError: values are transformed with onValue and errors with onError.
+// Unlike MapError, a failure stays a failure: if onError returns nil, the original error is returned.
+// Errors returned by onValue are not passed to onError.
+func BiMap[T, U any](resolvable Ctx[T], onValue func(T) (U, error), onError func(error) error) Ctx[U] {
+	return func(ctx context.Context) (U, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			var zero U
+			if mapped := onError(err); mapped != nil {
+				return zero, mapped
+			}
+			return zero, err
+		}
+		return onValue(v)
+	}
+}
+
+// Chain resolves first, builds the next resolvable from its value, and resolves it with the same context.
+// If first fails, next is not called and the zero value is returned with the error.
+//
+// Caching is independent at each stage: a cached first does not cache the resolvables built by next.
+func Chain[T, U any](first Ctx[T], next func(T) Ctx[U]) Ctx[U] {
+	return func(ctx context.Context) (U, error) {
+		v, err := first(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return next(v)(ctx)
+	}
+}
+
+// Pair holds two values resolved by Combine.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Combine resolves a and b concurrently with the same context and returns both values as a Pair.
+// If either fails, the context passed to the other is cancelled, like Sequence, and the zero Pair is
+// returned with the error. If both fail on their own, the errors are joined.
+func Combine[A, B any](a Ctx[A], b Ctx[B]) Ctx[Pair[A, B]] {
+	return func(ctx context.Context) (Pair[A, B], error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		var (
+			p    Pair[A, B]
+			errB error
+			done = make(chan struct{})
+		)
+		go func() {
+			defer close(done)
+			p.Second, errB = b(ctx)
+			if errB != nil {
+				cancel(errB)
+			}
+		}()
+		var errA error
+		p.First, errA = a(ctx)
+		if errA != nil {
+			cancel(errA)
+		}
+		<-done
+
+		if errA == nil && errB == nil {
+			return p, nil
+		}
+		// drop the error of the side that was cancelled because the other one failed
+		first := context.Cause(ctx)
+		if errors.Is(errA, context.Canceled) && first == errB {
+			errA = nil
+		}
+		if errors.Is(errB, context.Canceled) && first == errA {
+			errB = nil
+		}
+		return Pair[A, B]{}, errors.Join(errA, errB)
+	}
+}
+
+type SequenceOpts struct {
+	// Concurrent resolves all resolvables concurrently instead of one after the other.
+	Concurrent bool
+	// MaxConcurrency limits how many resolvables are resolved at the same time when Concurrent is set.
+	// Once the context is done, no more resolvables are started. Zero means unlimited.
+	MaxConcurrency int
+}
+
+// Sequence resolves each resolvable and returns their values in the same order.
+// It stops at the first error, which is returned with a nil slice. When resolving concurrently,
+// the first error cancels the context passed to the remaining resolvables.
+// An optional SequenceOpts may be passed to resolve concurrently.
+func Sequence[T any](resolvables []Ctx[T], opts ...SequenceOpts) Ctx[[]T] {
+	var o SequenceOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return func(ctx context.Context) ([]T, error) {
+		values, _, err := resolveAll(ctx, resolvables, o, true)
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+}
+
+// SequenceAll is like Sequence, but resolves all resolvables regardless of errors.
+// The values are returned alongside the errors joined using errors.Join. Failed resolvables
+// leave the value returned by the resolvable at their index.
+func SequenceAll[T any](resolvables []Ctx[T], opts ...SequenceOpts) Ctx[[]T] {
+	var o SequenceOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return func(ctx context.Context) ([]T, error) {
+		values, errs, _ := resolveAll(ctx, resolvables, o, false)
+		return values, errors.Join(errs...)
+	}
+}
+
+// AllSettled resolves all resolvables concurrently and returns the Result of each, in the same order.
+// It never fails as a whole: failures are reported in the Result at their index.
+func AllSettled[T any](resolvables []Ctx[T]) func(ctx context.Context) []Result[T] {
+	return func(ctx context.Context) []Result[T] {
+		values, errs, _ := resolveAll(ctx, resolvables, SequenceOpts{Concurrent: true}, false)
+		results := make([]Result[T], len(resolvables))
+		for i := range results {
+			results[i] = Result[T]{Value: values[i], Err: errs[i]}
+		}
+		return results
+	}
+}
+
+// Fold resolves each resolvable like Sequence and accumulates their values in order with fn, starting
+// from initial. If any of them fails, the zero value is returned with the first error.
+// An optional SequenceOpts may be passed to resolve concurrently, the values are still folded in order.
+func Fold[T, A any](resolvables []Ctx[T], initial A, fn func(A, T) A, opts ...SequenceOpts) Ctx[A] {
+	var o SequenceOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return func(ctx context.Context) (A, error) {
+		values, _, err := resolveAll(ctx, resolvables, o, true)
+		if err != nil {
+			var zero A
+			return zero, err
+		}
+		acc := initial
+		for _, v := range values {
+			acc = fn(acc, v)
+		}
+		return acc, nil
+	}
+}
+
+// resolveAll resolves all resolvables, returning their values and errors by index.
+// If failFast is set, it stops at the first error and returns it.
+func resolveAll[T any](ctx context.Context, resolvables []Ctx[T], opts SequenceOpts, failFast bool) (values []T, errs []error, first error) {
+	values = make([]T, len(resolvables))
+	errs = make([]error, len(resolvables))
+
+	if !opts.Concurrent {
+		for i, resolvable := range resolvables {
+			values[i], errs[i] = resolvable(ctx)
+			if errs[i] != nil && first == nil {
+				first = errs[i]
+				if failFast {
+					break
+				}
+			}
+		}
+		return values, errs, first
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		// sem limits the number of resolvables in flight, if set
+		sem chan struct{}
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			first = err
+			if failFast {
+				cancel()
+			}
+		})
+	}
+	if opts.MaxConcurrency > 0 {
+		sem = make(chan struct{}, opts.MaxConcurrency)
+	}
+dispatch:
+	for i, resolvable := range resolvables {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				// skip the rest
+				for j := i; j < len(resolvables); j++ {
+					errs[j] = ctx.Err()
+				}
+				fail(ctx.Err())
+				break dispatch
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			values[i], errs[i] = resolvable(ctx)
+			if errs[i] != nil {
+				fail(errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return values, errs, first
+}
+
+// Race resolves all resolvables concurrently and returns the first successful result, cancelling the
+// context passed to the others. If all of them fail, the zero value is returned with the errors joined.
+// Without any resolvables, ErrNoResolvables is returned.
+func Race[T any](resolvables ...Ctx[T]) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		if len(resolvables) == 0 {
+			var zero T
+			return zero, ErrNoResolvables
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// buffered so that the losers can always send their result and exit
+		results := make(chan Result[T], len(resolvables))
+		for _, resolvable := range resolvables {
+			go func() {
+				v, err := resolvable(ctx)
+				results <- Result[T]{Value: v, Err: err}
+			}()
+		}
+
+		errs := make([]error, 0, len(resolvables))
+		for range resolvables {
+			r := <-results
+			if r.Err == nil {
+				return r.Value, nil
+			}
+			errs = append(errs, r.Err)
+		}
+
+		var zero T
+		return zero, errors.Join(errs...)
+	}
+}
+
+// Hedge resolves primary, and if it hasn't returned within delay or has failed, also resolves backup.
+// It returns whichever succeeds first and cancels the context passed to the other. If both fail, the
+// zero value is returned with the errors joined.
+//
+// This reduces tail latency for redundant sources while sending most calls to primary only.
+func Hedge[T any](primary, backup Ctx[T], delay time.Duration) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// buffered so that the loser can always send its result and exit
+		results := make(chan Result[T], 2)
+		start := func(resolvable Ctx[T]) {
+			go func() {
+				v, err := resolvable(ctx)
+				results <- Result[T]{Value: v, Err: err}
+			}()
+		}
+		start(primary)
+		pending, hedged := 1, false
+		hedge := func() {
+			if !hedged {
+				hedged = true
+				pending++
+				start(backup)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		var errs []error
+		for {
+			select {
+			case <-timer.C:
+				hedge()
+			case r := <-results:
+				pending--
+				if r.Err == nil {
+					return r.Value, nil
+				}
+				errs = append(errs, r.Err)
+				// don't wait for the delay once primary has failed
+				hedge()
+				if pending == 0 {
+					var zero T
+					return zero, errors.Join(errs...)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,528 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallback(t *testing.T) {
+	ctx := context.Background()
+	errPrimary := errors.New("primary error")
+	errBackup := errors.New("backup error")
+	failing := func(err error) Ctx[int] {
+		return func(ctx context.Context) (int, error) {
+			return 1, err
+		}
+	}
+
+	t.Run("first success", func(t *testing.T) {
+		v := Fallback(failing(errPrimary), Static(2), Static(3))
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("all fail", func(t *testing.T) {
+		v := Fallback(failing(errPrimary), failing(errBackup))
+		value, err := v(ctx)
+		require.ErrorIs(t, err, errPrimary)
+		require.ErrorIs(t, err, errBackup)
+		assert.Equal(t, 0, value)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		var called bool
+		v := Fallback(func(ctx context.Context) (int, error) {
+			cancel()
+			return 0, errPrimary
+		}, func(ctx context.Context) (int, error) {
+			called = true
+			return 2, nil
+		})
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errPrimary)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.False(t, called)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := Fallback[int]()(ctx)
+		require.ErrorIs(t, err, ErrNoResolvables)
+	})
+}
+
+func TestDefault(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+
+	value, err := Default(Static(1), 2)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	value, err = Default(StaticError[int](errResolve), 2)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	t.Run("func", func(t *testing.T) {
+		v := DefaultFunc(StaticError[string](errResolve), func(err error) string {
+			return "default: " + err.Error()
+		})
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "default: resolve error", value)
+	})
+
+	t.Run("outside cache", func(t *testing.T) {
+		var count int
+		v := Default(Retry(Ctx[int](func(ctx context.Context) (int, error) {
+			count++
+			if count < 3 {
+				return 0, errResolve
+			}
+			return count, nil
+		})), -1)
+
+		for _, want := range []int{-1, -1, 3, 3} {
+			value, err := v(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, want, value)
+		}
+		assert.Equal(t, 3, count)
+	})
+}
+
+func TestTee(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	type outcome struct {
+		v   int
+		err error
+	}
+	var outcomes []outcome
+	sink := func(ctx context.Context, v int, err error) {
+		outcomes = append(outcomes, outcome{v, err})
+	}
+
+	value, err := Tee(Static(1), sink)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	_, err = Tee(StaticError[int](errResolve), sink)(ctx)
+	require.ErrorIs(t, err, errResolve)
+
+	assert.Equal(t, []outcome{{1, nil}, {0, errResolve}}, outcomes)
+}
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		v := Map(Static("42"), strconv.Atoi)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("fn error", func(t *testing.T) {
+		v := Map(Static("nope"), strconv.Atoi)
+		_, err := v(ctx)
+		require.ErrorIs(t, err, strconv.ErrSyntax)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		var called bool
+		v := Map(Ctx[string](func(ctx context.Context) (string, error) {
+			return "42", errors.New("resolve error")
+		}), func(s string) (int, error) {
+			called = true
+			return strconv.Atoi(s)
+		})
+
+		value, err := v(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.Equal(t, 0, value)
+		assert.False(t, called)
+	})
+}
+
+func TestMapError(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	errNotFound := errors.New("not found")
+
+	v := MapError(Static(1), func(err error) error {
+		t.Fatal("fn must not be called on success")
+		return err
+	})
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	v = MapError(StaticError[int](errResolve), func(err error) error {
+		return fmt.Errorf("%w: %w", errNotFound, err)
+	})
+	_, err = v(ctx)
+	require.ErrorIs(t, err, errNotFound)
+	require.ErrorIs(t, err, errResolve)
+
+	t.Run("nil", func(t *testing.T) {
+		v := MapError(Ctx[int](func(ctx context.Context) (int, error) {
+			return 1, errResolve
+		}), func(err error) error {
+			return nil
+		})
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, value)
+	})
+}
+
+func TestBiMap(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	errNotFound := errors.New("not found")
+	errInvalid := errors.New("invalid")
+
+	onValue := func(v int) (string, error) {
+		if v < 0 {
+			return "", errInvalid
+		}
+		return strconv.Itoa(v), nil
+	}
+	onError := func(err error) error {
+		return fmt.Errorf("%w: %w", errNotFound, err)
+	}
+
+	value, err := BiMap(Static(1), onValue, onError)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	_, err = BiMap(StaticError[int](errResolve), onValue, onError)(ctx)
+	require.ErrorIs(t, err, errNotFound)
+	require.ErrorIs(t, err, errResolve)
+
+	// errors from onValue are not mapped
+	_, err = BiMap(Static(-1), onValue, onError)(ctx)
+	require.ErrorIs(t, err, errInvalid)
+	require.NotErrorIs(t, err, errNotFound)
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := BiMap(StaticError[int](errResolve), onValue, func(err error) error { return nil })(ctx)
+		require.ErrorIs(t, err, errResolve)
+	})
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	fetch := func(token string) Ctx[string] {
+		return func(ctx context.Context) (string, error) {
+			return "data for " + token, nil
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		v := Chain(Static("token"), fetch)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "data for token", value)
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		var called bool
+		v := Chain(Ctx[string](func(ctx context.Context) (string, error) {
+			return "", errors.New("resolve error")
+		}), func(token string) Ctx[string] {
+			called = true
+			return fetch(token)
+		})
+
+		value, err := v(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.Empty(t, value)
+		assert.False(t, called)
+	})
+}
+
+func TestCombine(t *testing.T) {
+	ctx := context.Background()
+	errA := errors.New("a error")
+	errB := errors.New("b error")
+
+	t.Run("success", func(t *testing.T) {
+		v := Combine(Static(1), Static("b"))
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Pair[int, string]{First: 1, Second: "b"}, value)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// each side waits for the other to start
+		started := make(chan struct{})
+		v := Combine(func(ctx context.Context) (int, error) {
+			started <- struct{}{}
+			return 1, nil
+		}, func(ctx context.Context) (int, error) {
+			<-started
+			return 2, nil
+		})
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Pair[int, int]{First: 1, Second: 2}, value)
+	})
+
+	t.Run("one error", func(t *testing.T) {
+		v := Combine(Static(1), StaticError[string](errB))
+		value, err := v(ctx)
+		require.ErrorIs(t, err, errB)
+		assert.Zero(t, value)
+	})
+
+	t.Run("both errors", func(t *testing.T) {
+		v := Combine(StaticError[int](errA), StaticError[string](errB))
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errA)
+		require.ErrorIs(t, err, errB)
+	})
+
+	t.Run("cancels the other", func(t *testing.T) {
+		v := Combine(StaticError[int](errA), func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errA)
+		assert.NotErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestSequence(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+
+	for _, opts := range []SequenceOpts{{}, {Concurrent: true}, {Concurrent: true, MaxConcurrency: 1}} {
+		t.Run(fmt.Sprintf("concurrent=%v/max=%d", opts.Concurrent, opts.MaxConcurrency), func(t *testing.T) {
+			t.Run("success", func(t *testing.T) {
+				v := Sequence([]Ctx[int]{Static(1), Static(2), Static(3)}, opts)
+				values, err := v(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, []int{1, 2, 3}, values)
+			})
+
+			t.Run("error", func(t *testing.T) {
+				v := Sequence([]Ctx[int]{Static(1), StaticError[int](errResolve), Static(3)}, opts)
+				values, err := v(ctx)
+				require.ErrorIs(t, err, errResolve)
+				assert.Nil(t, values)
+			})
+
+			t.Run("all", func(t *testing.T) {
+				errOther := errors.New("other error")
+				v := SequenceAll([]Ctx[int]{Static(1), StaticError[int](errResolve), StaticError[int](errOther), Static(4)}, opts)
+				values, err := v(ctx)
+				require.ErrorIs(t, err, errResolve)
+				require.ErrorIs(t, err, errOther)
+				assert.Equal(t, []int{1, 0, 0, 4}, values)
+			})
+		})
+	}
+
+	t.Run("sequential stops at the first error", func(t *testing.T) {
+		var called bool
+		v := Sequence([]Ctx[int]{StaticError[int](errResolve), func(ctx context.Context) (int, error) {
+			called = true
+			return 2, nil
+		}})
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errResolve)
+		assert.False(t, called)
+	})
+
+	t.Run("concurrent cancels the rest", func(t *testing.T) {
+		v := Sequence([]Ctx[int]{StaticError[int](errResolve), func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}}, SequenceOpts{Concurrent: true})
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errResolve)
+	})
+
+	t.Run("max concurrency", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int32
+		resolvables := make([]Ctx[int], 20)
+		for i := range resolvables {
+			resolvables[i] = func(ctx context.Context) (int, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return i, nil
+			}
+		}
+
+		values, err := Sequence(resolvables, SequenceOpts{Concurrent: true, MaxConcurrency: 3})(ctx)
+		require.NoError(t, err)
+		assert.Len(t, values, 20)
+		assert.Equal(t, 19, values[19])
+		assert.EqualValues(t, 3, maxInFlight.Load())
+	})
+
+	t.Run("max concurrency stops dispatching", func(t *testing.T) {
+		var started atomic.Int32
+		blocking := func(ctx context.Context) (int, error) {
+			started.Add(1)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		v := SequenceAll([]Ctx[int]{StaticError[int](errResolve), blocking, blocking, blocking}, SequenceOpts{Concurrent: true, MaxConcurrency: 1})
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errResolve)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.EqualValues(t, 1, started.Load())
+	})
+}
+
+func TestAllSettled(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	slow := func(ctx context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	}
+	results := AllSettled([]Ctx[int]{slow, StaticError[int](errResolve), Static(3)})(ctx)
+	assert.Equal(t, []Result[int]{Ok(1), Err[int](errResolve), Ok(3)}, results)
+
+	assert.Empty(t, AllSettled[int](nil)(ctx))
+}
+
+func TestFold(t *testing.T) {
+	ctx := context.Background()
+	resolvables := []Ctx[int]{Static(1), Static(2), Static(3)}
+	concat := func(acc string, v int) string {
+		return acc + strconv.Itoa(v)
+	}
+
+	for _, opts := range []SequenceOpts{{}, {Concurrent: true}} {
+		t.Run(fmt.Sprintf("concurrent=%t", opts.Concurrent), func(t *testing.T) {
+			value, err := Fold(resolvables, ">", concat, opts)(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, ">123", value)
+
+			errResolve := errors.New("resolve error")
+			value, err = Fold(append(resolvables, StaticError[int](errResolve)), ">", concat, opts)(ctx)
+			require.ErrorIs(t, err, errResolve)
+			assert.Empty(t, value)
+		})
+	}
+}
+
+func TestRace(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first success", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		v := Race(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return 0, ctx.Err()
+		}, StaticError[int](errors.New("resolve error")), Static(2))
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+		// the loser is cancelled
+		<-cancelled
+	})
+
+	t.Run("all fail", func(t *testing.T) {
+		errA := errors.New("a error")
+		errB := errors.New("b error")
+		v := Race(StaticError[int](errA), StaticError[int](errB))
+
+		value, err := v(ctx)
+		require.ErrorIs(t, err, errA)
+		require.ErrorIs(t, err, errB)
+		assert.Equal(t, 0, value)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := Race[int]()(ctx)
+		require.ErrorIs(t, err, ErrNoResolvables)
+	})
+}
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+	errPrimary := errors.New("primary error")
+	errBackup := errors.New("backup error")
+	slow := func(v int, d time.Duration, cancelled *atomic.Bool) Ctx[int] {
+		return func(ctx context.Context) (int, error) {
+			select {
+			case <-time.After(d):
+				return v, nil
+			case <-ctx.Done():
+				if cancelled != nil {
+					cancelled.Store(true)
+				}
+				return 0, ctx.Err()
+			}
+		}
+	}
+
+	t.Run("fast primary", func(t *testing.T) {
+		var backupCalled atomic.Bool
+		v := Hedge(Static(1), func(ctx context.Context) (int, error) {
+			backupCalled.Store(true)
+			return 2, nil
+		}, 50*time.Millisecond)
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		time.Sleep(60 * time.Millisecond)
+		assert.False(t, backupCalled.Load())
+	})
+
+	t.Run("slow primary", func(t *testing.T) {
+		var cancelled atomic.Bool
+		v := Hedge(slow(1, time.Second, &cancelled), Static(2), 10*time.Millisecond)
+
+		start := time.Now()
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Eventually(t, cancelled.Load, time.Second, time.Millisecond)
+	})
+
+	t.Run("failing primary", func(t *testing.T) {
+		v := Hedge(StaticError[int](errPrimary), Static(2), time.Hour)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		v := Hedge(StaticError[int](errPrimary), StaticError[int](errBackup), time.Millisecond)
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errPrimary)
+		require.ErrorIs(t, err, errBackup)
+	})
+}
@@ -0,0 +1,21 @@
+package resolvable
+
+// Decorator wraps a resolvable to add a behavior, e.g. Safe or Once. The decorate package provides
+// the configurable decorators, such as Retry and Cache, in this form.
+type Decorator[T any] func(Ctx[T]) Ctx[T]
+
+// Compose applies the decorators to base from left to right: the first decorator wraps base directly
+// and the last one is the outermost, so it is called first. For example,
+//
+//	Compose(fn, decorate.Timeout[T](d), decorate.RetryLoop[T](retryOpts), decorate.Cache[T](cacheOpts))
+//
+// is equivalent to Cache(RetryLoop(Timeout(fn, d), retryOpts), cacheOpts): calls are served from the
+// cache, only cache misses are retried, and every attempt times out after d. Cache is already safe for
+// concurrent use, so don't wrap it with Safe, which would make cache hits wait for each other.
+func Compose[T any](base Ctx[T], decorators ...Decorator[T]) Ctx[T] {
+	resolvable := base
+	for _, decorate := range decorators {
+		resolvable = decorate(resolvable)
+	}
+	return resolvable
+}
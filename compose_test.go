@@ -0,0 +1,45 @@
+package resolvable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	ctx := context.Background()
+	var order []string
+	trace := func(name string) Decorator[int] {
+		return func(r Ctx[int]) Ctx[int] {
+			return func(ctx context.Context) (int, error) {
+				order = append(order, name)
+				return r(ctx)
+			}
+		}
+	}
+
+	// the last decorator is the outermost
+	v := Compose(Static(1), trace("inner"), trace("outer"))
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+
+	// generic decorators are passed as they are
+	var count int
+	v = Compose(func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}, Once, Safe)
+	_, _ = v(ctx)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// without decorators, base is returned as is
+	value, err = Compose(Static(2))(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
@@ -0,0 +1,18 @@
+package resolvable
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Counting returns the resolvable along with a function that reports how many times it was called,
+// e.g. to test how a resolvable is cached or retried without instrumenting it.
+// The count is safe to read concurrently with resolving.
+func Counting[T any](resolvable Ctx[T]) (Ctx[T], func() int) {
+	var count atomic.Int64
+	counted := func(ctx context.Context) (T, error) {
+		count.Add(1)
+		return resolvable(ctx)
+	}
+	return counted, func() int { return int(count.Load()) }
+}
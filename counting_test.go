@@ -0,0 +1,36 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounting(t *testing.T) {
+	ctx := context.Background()
+	fn, count := Counting(Static(1))
+	assert.Zero(t, count())
+
+	v := Cache(fn, CacheOpts{Expiry: time.Hour})
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := v(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, value)
+			count()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, count())
+
+	_, err := fn(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count())
+}
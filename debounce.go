@@ -0,0 +1,69 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Debounce coalesces calls that arrive within wait of each other into a single resolution, whose result
+// is shared by all of the waiting callers. The resolution starts once no new call has arrived for wait,
+// so a steady stream of calls keeps delaying it.
+//
+// The resolution runs with a context that is not cancelled along with the callers', so a cancelled
+// caller returns its context's error without failing the others.
+func Debounce[T any](resolvable Ctx[T], wait time.Duration) Ctx[T] {
+	d := &debouncer[T]{resolvable: resolvable, wait: wait}
+	return d.do
+}
+
+type debouncer[T any] struct {
+	resolvable Ctx[T]
+	wait       time.Duration
+
+	mu sync.Mutex
+	// call is the call waiting for the timer to fire, or nil.
+	call *debounceCall[T]
+}
+
+type debounceCall[T any] struct {
+	flightCall[T]
+	timer *time.Timer
+}
+
+func (d *debouncer[T]) do(ctx context.Context) (T, error) {
+	d.mu.Lock()
+	c := d.call
+	if c == nil {
+		c = d.start(detachInfo(context.WithoutCancel(ctx)))
+	} else if c.timer.Stop() {
+		// postpone the pending call
+		c.timer.Reset(d.wait)
+	}
+	// otherwise the timer already fired and the call is about to start, so join it
+	d.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// start schedules a new call. mu must be held.
+func (d *debouncer[T]) start(ctx context.Context) *debounceCall[T] {
+	c := &debounceCall[T]{flightCall: flightCall[T]{done: make(chan struct{})}}
+	c.timer = time.AfterFunc(d.wait, func() {
+		d.mu.Lock()
+		// later callers start a new call
+		d.call = nil
+		d.mu.Unlock()
+
+		c.value, c.err = d.resolvable(ctx)
+		close(c.done)
+	})
+	d.call = c
+	return c
+}
@@ -0,0 +1,73 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebounce(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	v := Debounce(func(ctx context.Context) (int, error) {
+		return int(count.Add(1)), nil
+	}, 50*time.Millisecond)
+
+	// a burst of calls results in a single resolution
+	const callers = 5
+	var (
+		wg     sync.WaitGroup
+		values [callers]int
+	)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			values[i], err = v(ctx)
+			assert.NoError(t, err)
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, count.Load())
+	for _, value := range values {
+		assert.Equal(t, 1, value)
+	}
+
+	// a later call resolves again
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	t.Run("cancelled caller", func(t *testing.T) {
+		var count atomic.Int32
+		v := Debounce(func(ctx context.Context) (int, error) {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+			return int(count.Add(1)), nil
+		}, 50*time.Millisecond)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			_, err := v(cancelled)
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		// the shared resolution is not aborted
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		assert.EqualValues(t, 1, count.Load())
+	})
+}
@@ -0,0 +1,126 @@
+// Package decorate provides the configurable decorators of the resolvable package as
+// resolvable.Decorator values, to be applied with resolvable.Compose.
+//
+// Decorators that take no configuration, such as resolvable.Safe and resolvable.Once, can be passed
+// to Compose as they are. Combinators of several resolvables, such as resolvable.Fallback, and those
+// that change the type of the value, such as resolvable.Map, are not decorators.
+package decorate
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamaln7/resolvable"
+)
+
+// Retry returns a decorator that applies resolvable.Retry.
+func Retry[T any](opts ...resolvable.RetryOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Retry(r, opts...)
+	}
+}
+
+// RetryLoop returns a decorator that applies resolvable.RetryLoop.
+func RetryLoop[T any](opts resolvable.RetryOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.RetryLoop(r, opts)
+	}
+}
+
+// Cache returns a decorator that applies resolvable.Cache.
+func Cache[T any](opts resolvable.CacheOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Cache(r, opts)
+	}
+}
+
+// TypedCache returns a decorator that applies resolvable.TypedCache.
+func TypedCache[T any](opts resolvable.TypedCacheOpts[T]) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.TypedCache(r, opts)
+	}
+}
+
+// Graceful returns a decorator that applies resolvable.Graceful.
+func Graceful[T any](opts ...resolvable.GracefulOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Graceful(r, opts...)
+	}
+}
+
+// Timeout returns a decorator that applies resolvable.Timeout.
+func Timeout[T any](d time.Duration) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Timeout(r, d)
+	}
+}
+
+// Validate returns a decorator that applies resolvable.Validate.
+func Validate[T any](fn func(T) error) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Validate(r, fn)
+	}
+}
+
+// RateLimit returns a decorator that applies resolvable.RateLimit.
+func RateLimit[T any](minInterval time.Duration, opts ...resolvable.RateLimitOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.RateLimit(r, minInterval, opts...)
+	}
+}
+
+// Throttle returns a decorator that applies resolvable.Throttle.
+func Throttle[T any](minInterval time.Duration, opts ...resolvable.RateLimitOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Throttle(r, minInterval, opts...)
+	}
+}
+
+// Debounce returns a decorator that applies resolvable.Debounce.
+func Debounce[T any](wait time.Duration) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Debounce(r, wait)
+	}
+}
+
+// Default returns a decorator that applies resolvable.Default.
+func Default[T any](fallback T) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Default(r, fallback)
+	}
+}
+
+// DefaultFunc returns a decorator that applies resolvable.DefaultFunc.
+func DefaultFunc[T any](fallback func(err error) T) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.DefaultFunc(r, fallback)
+	}
+}
+
+// MapError returns a decorator that applies resolvable.MapError.
+func MapError[T any](fn func(error) error) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.MapError(r, fn)
+	}
+}
+
+// Filter returns a decorator that applies resolvable.Filter.
+func Filter[T any](pred func(T) bool, errOnReject error) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Filter(r, pred, errOnReject)
+	}
+}
+
+// Tee returns a decorator that applies resolvable.Tee.
+func Tee[T any](sink func(ctx context.Context, v T, err error)) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.Tee(r, sink)
+	}
+}
+
+// CircuitBreaker returns a decorator that applies resolvable.CircuitBreaker.
+func CircuitBreaker[T any](opts resolvable.CircuitOpts) resolvable.Decorator[T] {
+	return func(r resolvable.Ctx[T]) resolvable.Ctx[T] {
+		return resolvable.CircuitBreaker(r, opts)
+	}
+}
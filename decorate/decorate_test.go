@@ -0,0 +1,86 @@
+package decorate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kamaln7/resolvable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	fn := func(ctx context.Context) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("resolve error")
+		}
+		return calls, nil
+	}
+
+	v := resolvable.Compose(fn,
+		Timeout[int](time.Second),
+		RetryLoop[int](resolvable.RetryOpts{Backoff: resolvable.NewConstantBackOff(0)}),
+		Cache[int](resolvable.CacheOpts{Expiry: time.Hour}),
+	)
+
+	// retried until it succeeds, then cached
+	for range 3 {
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+	}
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	v := resolvable.Compose(func(ctx context.Context) (int, error) {
+		calls++
+		if calls < 2 {
+			return 0, errors.New("resolve error")
+		}
+		return calls, nil
+	}, Retry[int]())
+
+	// retried on the next call, then cached forever
+	_, err := v(ctx)
+	require.Error(t, err)
+	for range 2 {
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	errNegative := errors.New("negative")
+	v := resolvable.Compose(resolvable.Static(-1), Validate(func(v int) error {
+		if v < 0 {
+			return errNegative
+		}
+		return nil
+	}))
+	_, err := v(ctx)
+	require.ErrorIs(t, err, errNegative)
+}
+
+func TestDefault(t *testing.T) {
+	ctx := context.Background()
+	errReject := errors.New("rejected")
+	v := resolvable.Compose(resolvable.Static(-1),
+		Filter(func(v int) bool { return v >= 0 }, errReject),
+		MapError[int](func(err error) error { return fmt.Errorf("wrapped: %w", err) }),
+		Default(0),
+	)
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, value)
+}
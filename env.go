@@ -0,0 +1,34 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrEnvNotSet is returned by Env when the environment variable is not set.
+var ErrEnvNotSet = errors.New("resolvable: environment variable not set")
+
+// Env returns a resolvable that reads the environment variable key on every call, failing with
+// ErrEnvNotSet if it is not set. A variable that is set to an empty string resolves to it.
+// Wrap it with Cache to pick up changes at an interval.
+func Env(key string) Ctx[string] {
+	return func(ctx context.Context) (string, error) {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrEnvNotSet, key)
+		}
+		return v, nil
+	}
+}
+
+// EnvDefault is like Env, but resolves to def if the environment variable is not set.
+func EnvDefault(key, def string) Ctx[string] {
+	return func(ctx context.Context) (string, error) {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, nil
+		}
+		return def, nil
+	}
+}
@@ -0,0 +1,36 @@
+package resolvable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	ctx := context.Background()
+	const key = "RESOLVABLE_TEST_ENV"
+
+	_, err := Env(key)(ctx)
+	require.ErrorIs(t, err, ErrEnvNotSet)
+	require.ErrorContains(t, err, key)
+	value, err := EnvDefault(key, "default")(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "default", value)
+
+	// read on every call
+	t.Setenv(key, "value")
+	value, err = Env(key)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+	value, err = EnvDefault(key, "default")(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// set but empty is not missing
+	t.Setenv(key, "")
+	value, err = Env(key)(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
@@ -0,0 +1,52 @@
+package resolvable
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// File returns a resolvable that reads the file at path and parses it with parse, e.g. to load a
+// configuration. The file is only read and parsed again once its modification time or size changes,
+// otherwise the previous result is returned, including a parse error.
+//
+// Errors are returned with the zero value, such as a missing file or a parse error, so wrap it with
+// Graceful to keep the last good value while the file is broken.
+// File is safe for concurrent use.
+func File[T any](path string, parse func([]byte) (T, error)) Ctx[T] {
+	var (
+		mu      sync.Mutex
+		loaded  bool
+		modTime time.Time
+		size    int64
+		value   T
+		err     error
+	)
+	return func(ctx context.Context) (T, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			var zero T
+			return zero, statErr
+		}
+		if loaded && info.ModTime().Equal(modTime) && info.Size() == size {
+			return value, err
+		}
+
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			var zero T
+			return zero, readErr
+		}
+		value, err = parse(data)
+		if err != nil {
+			var zero T
+			value = zero
+		}
+		loaded, modTime, size = true, info.ModTime(), info.Size()
+		return value, err
+	}
+}
@@ -0,0 +1,76 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "config")
+	var parses int
+	v := File(path, func(b []byte) (int, error) {
+		parses++
+		return strconv.Atoi(strings.TrimSpace(string(b)))
+	})
+	write := func(content string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	_, err := v(ctx)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	modTime := time.Now().Add(-time.Hour)
+	write("1", modTime)
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// not parsed again until the file changes
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, parses)
+
+	modTime = modTime.Add(time.Second)
+	write("2", modTime)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 2, parses)
+
+	// parse errors are returned until the file is fixed
+	modTime = modTime.Add(time.Second)
+	write("oops", modTime)
+	_, err = v(ctx)
+	var numErr *strconv.NumError
+	require.True(t, errors.As(err, &numErr))
+	_, err = v(ctx)
+	require.Error(t, err)
+	assert.Equal(t, 3, parses)
+
+	t.Run("graceful", func(t *testing.T) {
+		g := Graceful(v)
+		modTime = modTime.Add(time.Second)
+		write("3", modTime)
+		value, err := g(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+
+		require.NoError(t, os.Remove(path))
+		value, err = g(ctx)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Equal(t, 3, value)
+	})
+}
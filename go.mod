@@ -2,7 +2,7 @@ module github.com/kamaln7/resolvable
 
 go 1.24.3
 
-require github.com/stretchr/testify v1.10.0
+require github.com/stretchr/testify v1.11.1
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
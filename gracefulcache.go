@@ -0,0 +1,27 @@
+package resolvable
+
+import "time"
+
+// GracefulCacheOpts configures GracefulCache.
+type GracefulCacheOpts struct {
+	CacheOpts
+	// MaxStaleness is how long after it was resolved the last good value may be kept for. Once it is
+	// older, errors are cached and returned as they would be by Cache. Zero means forever.
+	MaxStaleness time.Duration
+}
+
+// GracefulCache is like Cache, but if resolving an expired value fails, the last good value stays
+// cached in place of the error, until the error would have expired or, with retries, until the next
+// attempt. Callers keep receiving the good value without an error, and the errors are only reported
+// to OnError.
+//
+// Unlike Graceful(Cache(...)), which returns the last good value alongside the error that replaced it
+// in the cache, GracefulCache holds on to the value itself, so that callers never see the error
+// until MaxStaleness is exceeded. The value is only passed to OnExpire once it is replaced.
+func GracefulCache[T any](resolvable Ctx[T], opts GracefulCacheOpts) Ctx[T] {
+	return NewTypedCache(resolvable, TypedCacheOpts[T]{
+		CacheOpts:    opts.CacheOpts,
+		keepGood:     true,
+		maxStaleness: opts.MaxStaleness,
+	}).Resolve
+}
@@ -0,0 +1,117 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGracefulCache(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	errResolve := errors.New("resolve error")
+	var (
+		count      int
+		resolveErr error
+		errs       int
+	)
+	v := GracefulCache(func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}, GracefulCacheOpts{
+		CacheOpts: CacheOpts{
+			Expiry:      time.Minute,
+			ErrorExpiry: 10 * time.Second,
+			Now:         clock.Now,
+			OnError:     func(ctx context.Context, err error) { errs++ },
+		},
+		MaxStaleness: 5 * time.Minute,
+	})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// failures past the TTL keep serving the good value
+	resolveErr = errResolve
+	clock.Add(time.Minute)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, errs)
+
+	// held until the error would have expired, then retried
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, count)
+	for range 3 {
+		clock.Add(10 * time.Second)
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	}
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 4, errs)
+
+	// a success replaces the held value
+	resolveErr = nil
+	clock.Add(10 * time.Second)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, value)
+
+	// past MaxStaleness, errors are returned
+	resolveErr = errResolve
+	clock.Add(time.Minute)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, value)
+	clock.Add(4*time.Minute - time.Second)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, value)
+	clock.Add(10 * time.Second)
+	_, err = v(ctx)
+	require.ErrorIs(t, err, errResolve)
+
+	t.Run("never resolved", func(t *testing.T) {
+		v := GracefulCache(StaticError[int](errResolve), GracefulCacheOpts{CacheOpts: CacheOpts{Expiry: time.Minute}})
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errResolve)
+	})
+
+	t.Run("on expire", func(t *testing.T) {
+		var (
+			resolveErr = errResolve
+			expired    []int
+			count      int
+		)
+		c := NewTypedCache(func(ctx context.Context) (int, error) {
+			count++
+			if count == 1 {
+				return count, nil
+			}
+			return count, resolveErr
+		}, TypedCacheOpts[int]{
+			CacheOpts: CacheOpts{Expiry: time.Minute, Now: clock.Now},
+			OnExpire:  func(old int) { expired = append(expired, old) },
+			keepGood:  true,
+		})
+		_, _ = c.Resolve(ctx)
+		clock.Add(time.Minute)
+		_, _ = c.Resolve(ctx)
+		assert.Empty(t, expired)
+
+		resolveErr = nil
+		clock.Add(time.Minute)
+		value, err := c.Resolve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+		assert.Equal(t, []int{1}, expired)
+	})
+}
@@ -0,0 +1,57 @@
+package resolvable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPStatusError is returned by HTTPJSON for responses with a non-2xx status code.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	// Body is the beginning of the response body, to help tell what went wrong.
+	Body string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("resolvable: GET %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// maxErrorBody is how much of the body of a failed response is kept in HTTPStatusError.
+const maxErrorBody = 1024
+
+// HTTPJSON returns a resolvable that GETs url with the resolve context and decodes the JSON response
+// body into a T. Responses with a non-2xx status code fail with an *HTTPStatusError.
+// A nil client uses http.DefaultClient.
+func HTTPJSON[T any](client *http.Client, url string) Ctx[T] {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) (T, error) {
+		var v T
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return v, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return v, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+			return v, &HTTPStatusError{URL: url, StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+			var zero T
+			return zero, fmt.Errorf("resolvable: decoding %s: %w", url, err)
+		}
+		return v, nil
+	}
+}
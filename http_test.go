@@ -0,0 +1,52 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPJSON(t *testing.T) {
+	ctx := context.Background()
+	type config struct {
+		Name string `json:"name"`
+	}
+	status := http.StatusOK
+	body := `{"name": "test"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	v := HTTPJSON[config](srv.Client(), srv.URL)
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, config{Name: "test"}, value)
+
+	status, body = http.StatusServiceUnavailable, "down for maintenance"
+	_, err = v(ctx)
+	var statusErr *HTTPStatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.Equal(t, "down for maintenance", statusErr.Body)
+	assert.Contains(t, err.Error(), "503 Service Unavailable")
+
+	status, body = http.StatusOK, "not json"
+	value, err = v(ctx)
+	require.ErrorContains(t, err, "decoding")
+	assert.Zero(t, value)
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := HTTPJSON[config](nil, srv.URL)(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
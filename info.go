@@ -0,0 +1,96 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ResolveInfo describes how a value was resolved.
+type ResolveInfo struct {
+	// FromCache is set if the value was served from a cache.
+	FromCache bool
+	// ResolvedAt is when the value was resolved.
+	ResolvedAt time.Time
+	// Attempts is the number of attempts it took to resolve the value.
+	Attempts int
+	// Name is the name of the resolvable as set by Named, if any.
+	Name string
+}
+
+type infoKey struct{}
+
+// WithInfo returns a resolvable that also returns information about how the value was resolved.
+//
+// The information is reported by the caches and retry loops in the resolvable, such as Cache and
+// RetryLoop. If there are none, the value is reported as resolved in a single attempt.
+//
+// Resolvables that resolve concurrently, such as Combine and Race, may all report to the same call.
+// The last report wins, and reports made after the call returned are dropped.
+func WithInfo[T any](resolvable Ctx[T]) func(ctx context.Context) (T, ResolveInfo, error) {
+	return func(ctx context.Context) (T, ResolveInfo, error) {
+		sink := &infoSink{}
+		v, err := resolvable(context.WithValue(ctx, infoKey{}, sink))
+		info := sink.finish()
+		if info.Attempts == 0 {
+			info.ResolvedAt = time.Now()
+			info.Attempts = 1
+		}
+		return v, info, err
+	}
+}
+
+// infoSink collects the ResolveInfo of a single call. It is safe for concurrent use.
+type infoSink struct {
+	mu   sync.Mutex
+	info ResolveInfo
+	// done is set once the call returned, after which reports are dropped.
+	done bool
+}
+
+// report replaces the info, keeping the name.
+func (s *infoSink) report(info ResolveInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		info.Name = s.info.Name
+		s.info = info
+	}
+}
+
+// name sets the name of the resolvable.
+func (s *infoSink) name(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		s.info.Name = name
+	}
+}
+
+// finish drops any further reports and returns the info.
+func (s *infoSink) finish() ResolveInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	return s.info
+}
+
+// infoFrom returns the infoSink to report to, or nil if WithInfo is not in use.
+func infoFrom(ctx context.Context) *infoSink {
+	sink, _ := ctx.Value(infoKey{}).(*infoSink)
+	return sink
+}
+
+// withInfoSink reports to sink instead of the caller's infoSink, e.g. to read back what the nested
+// resolvables reported without seeing the reports of concurrent callers.
+func withInfoSink(ctx context.Context, sink *infoSink) context.Context {
+	return context.WithValue(ctx, infoKey{}, sink)
+}
+
+// detachInfo stops reporting to the caller's infoSink, for work that may outlive the call.
+func detachInfo(ctx context.Context) context.Context {
+	if infoFrom(ctx) == nil {
+		return ctx
+	}
+	return withInfoSink(ctx, nil)
+}
@@ -0,0 +1,104 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithInfo(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("uncached", func(t *testing.T) {
+		v := WithInfo(Static(1))
+		value, info, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		assert.False(t, info.FromCache)
+		assert.Equal(t, 1, info.Attempts)
+		assert.False(t, info.ResolvedAt.IsZero())
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		var count int
+		v := WithInfo(New(func(ctx context.Context) (int, error) {
+			count++
+			if count < 3 {
+				return 0, errors.New("try again")
+			}
+			return count, nil
+		}, WithRetry(), WithNow(func() time.Time { return now })))
+
+		_, info, err := v(ctx)
+		require.Error(t, err)
+		assert.Equal(t, ResolveInfo{Attempts: 1, ResolvedAt: now}, info)
+		_, _, err = v(ctx)
+		require.Error(t, err)
+
+		value, info, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+		assert.Equal(t, ResolveInfo{Attempts: 3, ResolvedAt: now}, info)
+
+		value, info, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+		assert.Equal(t, ResolveInfo{FromCache: true, Attempts: 3, ResolvedAt: now}, info)
+	})
+
+	t.Run("retry loop", func(t *testing.T) {
+		var count int
+		loop := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			if count < 3 {
+				return 0, errors.New("try again")
+			}
+			return count, nil
+		}, RetryOpts{})
+		v := WithInfo(Once(loop))
+
+		value, info, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+		assert.False(t, info.FromCache)
+		assert.Equal(t, 3, info.Attempts)
+
+		_, info, err = v(ctx)
+		require.NoError(t, err)
+		assert.True(t, info.FromCache)
+		assert.Equal(t, 3, info.Attempts)
+	})
+}
+
+func TestWithInfoConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("combine", func(t *testing.T) {
+		v := WithInfo(Combine(Cache(Static(1), CacheOpts{}), Cache(Static("x"), CacheOpts{})))
+		for range 10 {
+			value, info, err := v(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, Pair[int, string]{First: 1, Second: "x"}, value)
+			assert.Equal(t, 1, info.Attempts)
+		}
+	})
+
+	t.Run("race", func(t *testing.T) {
+		slow := func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		v := WithInfo(Race(Once(Static(1)), RetryLoop(slow, RetryOpts{MaxTries: 100})))
+		for range 10 {
+			value, info, err := v(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, value)
+			assert.Equal(t, 1, info.Attempts)
+		}
+	})
+}
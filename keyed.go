@@ -0,0 +1,182 @@
+package resolvable
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type KeyedCacheOpts struct {
+	CacheOpts
+	// MaxEntries is the maximum number of cached keys. Once exceeded, the least recently used key is evicted.
+	// Zero means unlimited.
+	MaxEntries int
+}
+
+// TypedKeyedCacheOpts extends KeyedCacheOpts with options that depend on the types of the key and value.
+type TypedKeyedCacheOpts[K comparable, T any] struct {
+	// TypedCacheOpts configures the cache of every key. Its StoreKey is ignored in favor of StoreKey.
+	TypedCacheOpts[T]
+	// MaxEntries is the maximum number of cached keys. Once exceeded, the least recently used key is evicted.
+	// Zero means unlimited.
+	MaxEntries int
+	// StoreKey returns the key of a value in Store. Defaults to formatting the key with fmt.Sprint.
+	StoreKey func(key K) string
+}
+
+func (o *TypedKeyedCacheOpts[K, T]) storeKey(key K) string {
+	if o.StoreKey != nil {
+		return o.StoreKey(key)
+	}
+	return fmt.Sprint(key)
+}
+
+// KeyedCache caches the values of a parameterized resolvable by key.
+//
+// Every key is cached independently with its own expiry and error caching, as configured by CacheOpts.
+// Every key retries with its own clone of a CloneableBackOff; other backoffs are shared by all keys.
+// KeyedCache is safe for concurrent use, and resolving one key does not block resolving another:
+// every key has its own lock, and concurrent calls for the same key share a single resolution.
+// The lock of a key is dropped along with its entry, so MaxEntries also bounds the number of locks.
+// Expired keys are dropped as new keys are added, from the least recently used until the first key
+// that is still fresh or being resolved, so dropping them is amortized O(1).
+type KeyedCache[K comparable, T any] struct {
+	resolvable func(ctx context.Context, key K) (T, error)
+	opts       TypedKeyedCacheOpts[K, T]
+
+	// mu guards the entries, and is only held to look them up, never while resolving.
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	// recency orders the entries from most to least recently used.
+	recency *list.List
+}
+
+type keyedEntry[K comparable, T any] struct {
+	key   K
+	cache *Cached[T]
+}
+
+// NewKeyedCache creates a KeyedCache for the resolvable.
+func NewKeyedCache[K comparable, T any](resolvable func(ctx context.Context, key K) (T, error), opts KeyedCacheOpts) *KeyedCache[K, T] {
+	return NewTypedKeyedCache(resolvable, TypedKeyedCacheOpts[K, T]{
+		TypedCacheOpts: TypedCacheOpts[T]{CacheOpts: opts.CacheOpts},
+		MaxEntries:     opts.MaxEntries,
+	})
+}
+
+// NewTypedKeyedCache is like NewKeyedCache, with options that depend on the types of the key and value.
+func NewTypedKeyedCache[K comparable, T any](resolvable func(ctx context.Context, key K) (T, error), opts TypedKeyedCacheOpts[K, T]) *KeyedCache[K, T] {
+	if _, ok := opts.RetryOpts.Backoff.(CloneableBackOff); opts.Retry && opts.RetryOpts.Backoff != nil && !ok {
+		// keys resolve concurrently, and all share a backoff that can't be cloned
+		opts.RetryOpts.Backoff = &lockedBackOff{b: opts.RetryOpts.Backoff}
+	}
+	return &KeyedCache[K, T]{
+		resolvable: resolvable,
+		opts:       opts,
+		entries:    make(map[K]*list.Element),
+		recency:    list.New(),
+	}
+}
+
+// Resolve returns the cached value for key, resolving it if it is missing or expired.
+func (c *KeyedCache[K, T]) Resolve(ctx context.Context, key K) (T, error) {
+	return c.entry(key).Resolve(ctx)
+}
+
+// Invalidate removes the cached value for key, so that the next call to Resolve resolves it again.
+func (c *KeyedCache[K, T]) Invalidate(key K) {
+	if c.opts.Store != nil {
+		// keep an entry that remembers not to load the invalidated value from the store
+		c.entry(key).Invalidate()
+		return
+	}
+
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if ok {
+		c.remove(el)
+	}
+	c.mu.Unlock()
+
+	if ok && c.opts.CloseOnEvict {
+		// evict the value of the removed cache
+		el.Value.(*keyedEntry[K, T]).cache.Invalidate()
+	}
+}
+
+// Close closes the caches of all keys, see Cached.Close. Close is safe to call more than once.
+func (c *KeyedCache[K, T]) Close() error {
+	c.mu.Lock()
+	caches := make([]*Cached[T], 0, len(c.entries))
+	for _, el := range c.entries {
+		caches = append(caches, el.Value.(*keyedEntry[K, T]).cache)
+	}
+	c.mu.Unlock()
+
+	for _, cache := range caches {
+		_ = cache.Close()
+	}
+	return nil
+}
+
+// Len returns the number of cached keys, including expired keys that were not dropped yet.
+func (c *KeyedCache[K, T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *KeyedCache[K, T]) entry(key K) *Cached[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.recency.MoveToFront(el)
+		return el.Value.(*keyedEntry[K, T]).cache
+	}
+
+	opts := c.opts.TypedCacheOpts
+	if opts.Store != nil {
+		opts.StoreKey = c.opts.storeKey(key)
+	}
+	e := NewTypedCache(func(ctx context.Context) (T, error) {
+		return c.resolvable(ctx, key)
+	}, opts)
+	c.entries[key] = c.recency.PushFront(&keyedEntry[K, T]{key: key, cache: e})
+	// the least recently used keys are the most likely to have expired
+	for el := c.recency.Back(); el != nil; el = c.recency.Back() {
+		if !c.prune(el) {
+			break
+		}
+	}
+	if c.opts.MaxEntries > 0 && c.recency.Len() > c.opts.MaxEntries {
+		evicted := c.recency.Back()
+		c.remove(evicted)
+		c.evict(evicted)
+	}
+	return e
+}
+
+// prune removes an entry if its value has expired and is not being resolved again, and reports
+// whether it did. mu must be held.
+func (c *KeyedCache[K, T]) prune(el *list.Element) bool {
+	if !el.Value.(*keyedEntry[K, T]).cache.dropExpired() {
+		return false
+	}
+	c.remove(el)
+	c.evict(el)
+	return true
+}
+
+// evict closes the value of a removed entry if CloseOnEvict is set, see Cached.evict.
+func (c *KeyedCache[K, T]) evict(el *list.Element) {
+	if c.opts.CloseOnEvict {
+		el.Value.(*keyedEntry[K, T]).cache.evict()
+	}
+}
+
+// remove deletes an entry from both the map and the recency list. mu must be held.
+func (c *KeyedCache[K, T]) remove(el *list.Element) {
+	c.recency.Remove(el)
+	delete(c.entries, el.Value.(*keyedEntry[K, T]).key)
+}
@@ -0,0 +1,274 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		calls      = map[int]int{}
+		resolveErr error
+	)
+	c := NewKeyedCache(func(ctx context.Context, id int) (string, error) {
+		calls[id]++
+		return fmt.Sprintf("user %d (%d)", id, calls[id]), resolveErr
+	}, KeyedCacheOpts{CacheOpts: CacheOpts{
+		Expiry: time.Minute,
+		Now:    func() time.Time { return now },
+	}})
+
+	value, err := c.Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (1)", value)
+
+	value, err = c.Resolve(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "user 2 (1)", value)
+	assert.Equal(t, 2, c.Len())
+
+	// cached per key
+	value, err = c.Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (1)", value)
+
+	// each key expires independently
+	now = now.Add(30 * time.Second)
+	resolveErr = errors.New("resolve error")
+	_, err = c.Resolve(ctx, 3)
+	require.EqualError(t, err, "resolve error")
+
+	now = now.Add(30 * time.Second)
+	resolveErr = nil
+	value, err = c.Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (2)", value)
+
+	// the error for key 3 is still cached
+	_, err = c.Resolve(ctx, 3)
+	require.EqualError(t, err, "resolve error")
+
+	c.Invalidate(2)
+	assert.Equal(t, 2, c.Len())
+	value, err = c.Resolve(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "user 2 (2)", value)
+
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+}
+
+func TestKeyedCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	calls := map[string]int{}
+	c := NewKeyedCache(func(ctx context.Context, key string) (int, error) {
+		calls[key]++
+		return calls[key], nil
+	}, KeyedCacheOpts{MaxEntries: 3})
+
+	resolve := func(key string) int {
+		value, err := c.Resolve(ctx, key)
+		require.NoError(t, err)
+		return value
+	}
+
+	resolve("a")
+	resolve("b")
+	resolve("c")
+	// a is now the most recently used
+	assert.Equal(t, 1, resolve("a"))
+
+	// b is the least recently used and gets evicted
+	resolve("d")
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, 1, resolve("a"))
+	assert.Equal(t, 1, resolve("c"))
+	assert.Equal(t, 1, resolve("d"))
+
+	// b is resolved again, evicting a
+	assert.Equal(t, 2, resolve("b"))
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, 2, resolve("a"))
+
+	// invalidated keys are removed from the recency list too
+	c.Invalidate("b")
+	c.Invalidate("c")
+	assert.Equal(t, 2, c.Len())
+	resolve("e")
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, 2, resolve("a"))
+	assert.Equal(t, 1, resolve("d"))
+}
+
+func TestKeyedCache_Expired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var expired []string
+	c := NewTypedKeyedCache(func(ctx context.Context, key string) (string, error) {
+		return key, nil
+	}, TypedKeyedCacheOpts[string, string]{
+		TypedCacheOpts: TypedCacheOpts[string]{
+			CacheOpts: CacheOpts{Expiry: time.Minute, Now: func() time.Time { return now }},
+			OnExpire:  func(old string) { expired = append(expired, old) },
+		},
+		MaxEntries: 3,
+	})
+	resolve := func(key string) {
+		_, err := c.Resolve(ctx, key)
+		require.NoError(t, err)
+	}
+
+	resolve("a")
+	resolve("b")
+	now = now.Add(30 * time.Second)
+	resolve("c")
+	resolve("a")
+	now = now.Add(30 * time.Second)
+
+	// b is the least recently used and is removed from the map and the recency list once expired
+	resolve("d")
+	assert.Equal(t, []string{"b"}, expired)
+	assert.Len(t, c.entries, 3)
+	assert.Equal(t, 3, c.recency.Len())
+	assert.NotContains(t, c.entries, "b")
+
+	// a expired too, but pruning stops at c, which is still fresh and is evicted instead
+	resolve("e")
+	assert.Equal(t, []string{"b"}, expired)
+	assert.Contains(t, c.entries, "a")
+	assert.NotContains(t, c.entries, "c")
+	assert.Equal(t, 3, c.Len())
+
+	// expired keys are dropped from the back up to the new key
+	now = now.Add(time.Minute)
+	resolve("f")
+	assert.Equal(t, []string{"b", "a", "d", "e"}, expired)
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 1, c.recency.Len())
+}
+
+// sharedBackOff is a BackOff that is not a CloneableBackOff.
+type sharedBackOff struct{ calls int }
+
+func (b *sharedBackOff) NextBackOff() time.Duration {
+	b.calls++
+	// give the other keys time to call it concurrently
+	time.Sleep(time.Millisecond)
+	return 0
+}
+
+func (b *sharedBackOff) Reset() { b.calls = 0 }
+
+func TestKeyedCache_SharedBackOff(t *testing.T) {
+	ctx := context.Background()
+	start := make(chan struct{})
+	c := NewKeyedCache(func(ctx context.Context, key int) (int, error) {
+		<-start
+		return 0, errors.New("resolve error")
+	}, KeyedCacheOpts{CacheOpts: CacheOpts{Retry: true, RetryOpts: RetryOpts{Backoff: &sharedBackOff{}}}})
+
+	// run with -race, the keys retry concurrently with the same backoff
+	var wg sync.WaitGroup
+	for key := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				_, err := c.Resolve(ctx, key)
+				assert.Error(t, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestKeyedCache_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	var (
+		calls   sync.Map
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	c := NewKeyedCache(func(ctx context.Context, key string) (string, error) {
+		n, _ := calls.LoadOrStore(key, new(atomic.Int32))
+		n.(*atomic.Int32).Add(1)
+		if key == "slow" {
+			close(started)
+			<-release
+		}
+		return key, nil
+	}, KeyedCacheOpts{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Resolve(ctx, "slow")
+			assert.NoError(t, err)
+			assert.Equal(t, "slow", value)
+		}()
+	}
+
+	// other keys are resolved while slow is in flight
+	<-started
+	for i := range 5 {
+		key := strconv.Itoa(i)
+		value, err := c.Resolve(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, key, value)
+	}
+	assert.Equal(t, 6, c.Len())
+
+	close(release)
+	wg.Wait()
+	// concurrent calls for the same key shared a single resolution
+	n, _ := calls.Load("slow")
+	assert.EqualValues(t, 1, n.(*atomic.Int32).Load())
+}
+
+func BenchmarkKeyedCache(b *testing.B) {
+	ctx := context.Background()
+	const keys = 1024
+
+	b.Run("hit", func(b *testing.B) {
+		c := NewKeyedCache(func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		}, KeyedCacheOpts{})
+		for key := range keys {
+			_, _ = c.Resolve(ctx, key)
+		}
+		var next atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = c.Resolve(ctx, int(next.Add(1)%keys))
+			}
+		})
+	})
+
+	b.Run("distinct keys", func(b *testing.B) {
+		// every call resolves a new key, which takes a while; distinct keys are resolved in parallel
+		c := NewKeyedCache(func(ctx context.Context, key int64) (int64, error) {
+			time.Sleep(100 * time.Microsecond)
+			return key, nil
+		}, KeyedCacheOpts{MaxEntries: keys})
+		var next atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = c.Resolve(ctx, next.Add(1))
+			}
+		})
+	})
+}
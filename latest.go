@@ -0,0 +1,39 @@
+package resolvable
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Latest resolves the resolvable on every call and remembers the most recent successful value.
+// If the resolvable fails after having succeeded once, that value is returned without an error; the
+// error is only returned if it has never succeeded. The returned function reads the latest successful
+// value without resolving, reporting false if there is none yet.
+//
+// Unlike Graceful, which returns the last known good value alongside the error so that callers can
+// tell it is stale, Latest hides the error entirely. And unlike Cached.Peek, which reads the entry of
+// a cache that may hold an error, the returned function only ever reads successful values.
+//
+// Latest is safe for concurrent use if the resolvable is.
+func Latest[T any](resolvable Ctx[T]) (Ctx[T], func() (T, bool)) {
+	var latest atomic.Pointer[T]
+	resolve := func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			if last := latest.Load(); last != nil {
+				return *last, nil
+			}
+			return v, err
+		}
+		latest.Store(&v)
+		return v, nil
+	}
+	get := func() (T, bool) {
+		if last := latest.Load(); last != nil {
+			return *last, true
+		}
+		var zero T
+		return zero, false
+	}
+	return resolve, get
+}
@@ -0,0 +1,56 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	var (
+		count      int
+		resolveErr = errResolve
+	)
+	v, latest := Latest(func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	})
+
+	_, ok := latest()
+	assert.False(t, ok)
+
+	// fails until it has succeeded once
+	_, err := v(ctx)
+	require.ErrorIs(t, err, errResolve)
+	_, ok = latest()
+	assert.False(t, ok)
+
+	resolveErr = nil
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	// then failures return the latest value
+	resolveErr = errResolve
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	value, ok = latest()
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	// reading it doesn't resolve
+	assert.Equal(t, 3, count)
+
+	resolveErr = nil
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, value)
+	value, _ = latest()
+	assert.Equal(t, 4, value)
+}
@@ -0,0 +1,37 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+)
+
+// Lazy defers building a resolvable until it is first resolved, e.g. to avoid expensive setup at
+// package-init time. build is called exactly once, and the resolvable it returns is used for every call.
+//
+// To surface a setup error, return StaticError from build; it is then returned by every call.
+// If build panics, every call returns a *PanicError instead, and if it returns nil, an error.
+func Lazy[T any](build func() Ctx[T]) Ctx[T] {
+	var (
+		once       sync.Once
+		resolvable Ctx[T]
+	)
+	return func(ctx context.Context) (T, error) {
+		once.Do(func() {
+			defer func() {
+				if r := recover(); r != nil {
+					resolvable = StaticError[T](&PanicError{Value: r, Stack: debug.Stack()})
+				}
+			}()
+			resolvable = build()
+			if resolvable == nil {
+				resolvable = StaticError[T](errLazyNil)
+			}
+		})
+		return resolvable(ctx)
+	}
+}
+
+// errLazyNil is returned by Lazy when build returns nil.
+var errLazyNil = errors.New("resolvable: Lazy build returned a nil resolvable")
@@ -0,0 +1,72 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	ctx := context.Background()
+	var builds atomic.Int64
+	v := Lazy(func() Ctx[int] {
+		builds.Add(1)
+		return Static(1)
+	})
+	assert.Zero(t, builds.Load())
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := v(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, value)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, builds.Load())
+
+	t.Run("build error", func(t *testing.T) {
+		errBuild := errors.New("build error")
+		var builds int
+		v := Lazy(func() Ctx[int] {
+			builds++
+			return StaticError[int](errBuild)
+		})
+
+		for range 2 {
+			_, err := v(ctx)
+			require.ErrorIs(t, err, errBuild)
+		}
+		assert.Equal(t, 1, builds)
+	})
+
+	t.Run("build panics", func(t *testing.T) {
+		v := Lazy(func() Ctx[int] {
+			panic("build panic")
+		})
+
+		for range 2 {
+			_, err := v(ctx)
+			var panicErr *PanicError
+			require.ErrorAs(t, err, &panicErr)
+			assert.Equal(t, "build panic", panicErr.Value)
+		}
+	})
+
+	t.Run("build returns nil", func(t *testing.T) {
+		v := Lazy(func() Ctx[int] { return nil })
+
+		for range 2 {
+			_, err := v(ctx)
+			require.EqualError(t, err, "resolvable: Lazy build returned a nil resolvable")
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package resolvable
+
+// CloseFunc stops the background work of a resolvable, such as the goroutines of Refreshing and
+// Watch, and waits for it to finish. It implements io.Closer, and is safe to call more than once.
+//
+// The background work runs until it is closed, so failing to call it leaks goroutines.
+type CloseFunc func()
+
+// Close calls f. It always returns nil.
+func (f CloseFunc) Close() error {
+	f()
+	return nil
+}
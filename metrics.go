@@ -0,0 +1,39 @@
+package resolvable
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics receives measurements from caches and retry loops, e.g. to export them to Prometheus.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncHit is called when a value is returned from a cache.
+	IncHit()
+	// IncMiss is called when a cache has to resolve a missing or expired value.
+	IncMiss()
+	// IncError is called whenever the underlying function fails, including attempts that are retried.
+	IncError()
+	// ObserveDuration is called with how long every call to the underlying function took.
+	ObserveDuration(d time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncHit()                       {}
+func (noopMetrics) IncMiss()                      {}
+func (noopMetrics) IncError()                     {}
+func (noopMetrics) ObserveDuration(time.Duration) {}
+
+// metered records the duration and errors of every call to the resolvable.
+func metered[T any](resolvable Ctx[T], m Metrics) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		start := time.Now()
+		v, err := resolvable(ctx)
+		m.ObserveDuration(time.Since(start))
+		if err != nil {
+			m.IncError()
+		}
+		return v, err
+	}
+}
@@ -0,0 +1,83 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	hits, misses, errors, observed atomic.Int32
+}
+
+func (m *testMetrics) IncHit()                       { m.hits.Add(1) }
+func (m *testMetrics) IncMiss()                      { m.misses.Add(1) }
+func (m *testMetrics) IncError()                     { m.errors.Add(1) }
+func (m *testMetrics) ObserveDuration(time.Duration) { m.observed.Add(1) }
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+
+	t.Run("cache", func(t *testing.T) {
+		var (
+			m     testMetrics
+			calls int
+		)
+		v := New(func(ctx context.Context) (int, error) {
+			calls++
+			if calls == 1 {
+				return 0, errResolve
+			}
+			return calls, nil
+		}, WithRetry(), WithMetrics(&m))
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errResolve)
+		for range 3 {
+			_, err = v(ctx)
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, 2, m.misses.Load())
+		assert.EqualValues(t, 2, m.hits.Load())
+		assert.EqualValues(t, 1, m.errors.Load())
+		assert.EqualValues(t, 2, m.observed.Load())
+	})
+
+	t.Run("retry loop", func(t *testing.T) {
+		var (
+			m     testMetrics
+			calls int
+		)
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, errResolve
+			}
+			return calls, nil
+		}, RetryOpts{Metrics: &m})
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+		assert.EqualValues(t, 2, m.errors.Load())
+		assert.EqualValues(t, 3, m.observed.Load())
+		assert.Zero(t, m.hits.Load())
+		assert.Zero(t, m.misses.Load())
+	})
+
+	t.Run("uncached", func(t *testing.T) {
+		var m testMetrics
+		v := New(StaticError[int](errResolve), WithMetrics(&m))
+		_, _ = v(ctx)
+		_, _ = v(ctx)
+		assert.EqualValues(t, 2, m.errors.Load())
+		assert.EqualValues(t, 2, m.observed.Load())
+		assert.Zero(t, m.misses.Load())
+	})
+}
@@ -0,0 +1,29 @@
+package resolvable
+
+import (
+	"context"
+	"fmt"
+)
+
+// Must returns a function that resolves the value and panics if resolution fails.
+// It is meant for values that are required at startup and have no sensible fallback.
+func Must[T any](resolvable Ctx[T]) func(ctx context.Context) T {
+	return func(ctx context.Context) T {
+		v, err := resolvable(ctx)
+		if err != nil {
+			panic(fmt.Errorf("resolvable: must resolve: %w", err))
+		}
+		return v
+	}
+}
+
+// MustV is like Must for a V.
+func MustV[T any](resolvable V[T]) func() T {
+	return func() T {
+		v, err := resolvable()
+		if err != nil {
+			panic(fmt.Errorf("resolvable: must resolve: %w", err))
+		}
+		return v
+	}
+}
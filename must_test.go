@@ -0,0 +1,24 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMust(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+
+	assert.Equal(t, 1, Must(Static(1))(ctx))
+	assert.PanicsWithError(t, "resolvable: must resolve: resolve error", func() {
+		Must(StaticError[int](errResolve))(ctx)
+	})
+
+	assert.Equal(t, 1, MustV(Static(1).WithContext(ctx))())
+	assert.PanicsWithError(t, "resolvable: must resolve: resolve error", func() {
+		MustV(StaticError[int](errResolve).WithBackgroundContext())()
+	})
+}
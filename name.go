@@ -0,0 +1,26 @@
+package resolvable
+
+import "context"
+
+type nameKey struct{}
+
+// Named labels the resolvable with a name, e.g. to tell resolvables apart in logs, metrics and traces.
+//
+// The name is available to everything inside the resolvable, such as the OnError and OnResolve hooks,
+// through NameFrom. It is also reported to WithInfo, where the outermost name wins.
+func Named[T any](resolvable Ctx[T], name string) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(context.WithValue(ctx, nameKey{}, name))
+		if info := infoFrom(ctx); info != nil {
+			info.name(name)
+		}
+		return v, err
+	}
+}
+
+// NameFrom returns the name of the innermost Named resolvable the context was passed to, or an empty
+// string if there is none.
+func NameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
@@ -0,0 +1,48 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, NameFrom(ctx))
+
+	var names []string
+	v := Named(Named(func(ctx context.Context) (int, error) {
+		names = append(names, NameFrom(ctx))
+		return 1, nil
+	}, "inner"), "outer")
+
+	_, info, err := WithInfo(v)(ctx)
+	require.NoError(t, err)
+	// the innermost name is visible inside, the outermost one is reported
+	assert.Equal(t, []string{"inner"}, names)
+	assert.Equal(t, "outer", info.Name)
+}
+
+func TestWithName(t *testing.T) {
+	ctx := context.Background()
+	logger := &testLogger{}
+	var hookNames []string
+	v := New(StaticError[int](errors.New("resolve error")),
+		WithRetry(),
+		WithName("config"),
+		WithLogger(logger),
+		WithOnError(func(ctx context.Context, err error) {
+			hookNames = append(hookNames, NameFrom(ctx))
+		}),
+	)
+
+	_, info, err := WithInfo(v)(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "config", info.Name)
+	assert.Equal(t, []string{"config"}, hookNames)
+	require.Len(t, logger.lines, 1)
+	assert.Regexp(t, "^config: resolvable: resolved", logger.lines[0])
+}
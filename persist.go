@@ -0,0 +1,66 @@
+package resolvable
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+)
+
+// cacheSnapshot is the gob-encoded state of a Cached.
+type cacheSnapshot[T any] struct {
+	Resolved bool
+	Value    T
+	// Err is the error message, since errors are not gob-encodable.
+	Err         string
+	HasErr      bool
+	ResolvedAt  time.Time
+	NextResolve time.Time
+}
+
+// SaveTo writes the cached value, error, and expiry to w with encoding/gob, so that they can be restored
+// with LoadFrom, e.g. after a restart. T must be encodable by encoding/gob.
+func (e *Cached[T]) SaveTo(w io.Writer) error {
+	var snapshot cacheSnapshot[T]
+	if entry := e.entry.Load(); entry != nil {
+		snapshot = cacheSnapshot[T]{
+			Resolved:    true,
+			Value:       entry.value,
+			ResolvedAt:  entry.resolvedAt,
+			NextResolve: entry.nextResolve,
+		}
+		if entry.err != nil {
+			snapshot.Err, snapshot.HasErr = entry.err.Error(), true
+		}
+	}
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// LoadFrom replaces the cached value with one written by SaveTo. A cached error is restored as an error
+// with the same message, but not the same type. The value expires when it would have originally.
+func (e *Cached[T]) LoadFrom(r io.Reader) error {
+	var snapshot cacheSnapshot[T]
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures = 0
+	if !snapshot.Resolved {
+		e.replace(context.Background(), nil)
+		return nil
+	}
+	entry := &cacheEntry[T]{
+		value:       snapshot.Value,
+		resolvedAt:  snapshot.ResolvedAt,
+		attempts:    1,
+		nextResolve: snapshot.NextResolve,
+	}
+	if snapshot.HasErr {
+		entry.err = errors.New(snapshot.Err)
+	}
+	e.replace(context.Background(), entry)
+	return nil
+}
@@ -0,0 +1,74 @@
+package resolvable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached_SaveTo(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	type config struct {
+		Name  string
+		Ports []int
+	}
+	var count int
+	fn := func(ctx context.Context) (config, error) {
+		count++
+		return config{Name: "app", Ports: []int{count}}, nil
+	}
+	opts := CacheOpts{Expiry: time.Minute, Now: clock.Now}
+
+	c := NewCache(fn, opts)
+	_, err := c.Resolve(ctx)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.SaveTo(&buf))
+
+	restored := NewCache(fn, opts)
+	require.NoError(t, restored.LoadFrom(&buf))
+	value, err := restored.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, config{Name: "app", Ports: []int{1}}, value)
+	assert.Equal(t, 1, count)
+
+	// the restored value expires when the original would have
+	clock.Add(time.Minute)
+	value, err = restored.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, value.Ports)
+
+	t.Run("error", func(t *testing.T) {
+		c := NewCache(StaticError[int](errors.New("resolve error")), CacheOpts{})
+		_, err := c.Resolve(ctx)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, c.SaveTo(&buf))
+		restored := NewCache(Static(1), CacheOpts{})
+		require.NoError(t, restored.LoadFrom(&buf))
+		_, err = restored.Resolve(ctx)
+		require.EqualError(t, err, "resolve error")
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, NewCache(Static(1), CacheOpts{}).SaveTo(&buf))
+
+		restored := NewCache(Static(2), CacheOpts{})
+		require.NoError(t, restored.LoadFrom(&buf))
+		_, ok, _ := restored.Peek()
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Error(t, NewCache(Static(1), CacheOpts{}).LoadFrom(bytes.NewBufferString("invalid")))
+	})
+}
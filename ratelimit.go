@@ -0,0 +1,90 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitOpts configures RateLimit and Throttle.
+type RateLimitOpts struct {
+	// Now sets a custom time.Now function.
+	Now func() time.Time
+}
+
+func (o *RateLimitOpts) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// RateLimit calls the resolvable at most once per minInterval. Calls that arrive sooner receive the
+// result of the most recent resolution. Unlike Cache, which is about freshness, RateLimit protects
+// the upstream: it applies even without a TTL and knows nothing about expiry.
+//
+// RateLimit is safe for concurrent use. Callers waiting for an in-flight resolution return the
+// context's error if it is done first. An optional RateLimitOpts may be passed to set the clock.
+func RateLimit[T any](resolvable Ctx[T], minInterval time.Duration, opts ...RateLimitOpts) Ctx[T] {
+	var o RateLimitOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	var (
+		// sem guards the fields below, and unlike a mutex, waiting on it can be abandoned
+		sem        = make(chan struct{}, 1)
+		resolved   bool
+		resolvedAt time.Time
+		value      T
+		err        error
+	)
+	return func(ctx context.Context) (T, error) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		defer func() { <-sem }()
+
+		if resolved && o.now().Sub(resolvedAt) < minInterval {
+			return value, err
+		}
+
+		resolvedAt = o.now()
+		value, err = resolvable(ctx)
+		resolved = true
+		return value, err
+	}
+}
+
+// ErrThrottled is returned by Throttle when it is called too frequently.
+var ErrThrottled = errors.New("resolvable: throttled")
+
+// Throttle calls the resolvable at most once per minInterval, like RateLimit, but calls that arrive
+// sooner return the zero value and ErrThrottled instead of the last result. This is useful to guard
+// expensive operations such as manual refreshes.
+//
+// Throttle is safe for concurrent use if the resolvable is. An optional RateLimitOpts may be passed to
+// set the clock.
+func Throttle[T any](resolvable Ctx[T], minInterval time.Duration, opts ...RateLimitOpts) Ctx[T] {
+	var o RateLimitOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	// intervals are measured from start, so that they use the monotonic clock
+	start := o.now()
+	// lastAttempt is the time of the last attempt since start, or -1
+	var lastAttempt atomic.Int64
+	lastAttempt.Store(-1)
+	return func(ctx context.Context) (T, error) {
+		last, now := lastAttempt.Load(), int64(o.now().Sub(start))
+		if (last >= 0 && time.Duration(now-last) < minInterval) || !lastAttempt.CompareAndSwap(last, now) {
+			// too soon, or another caller just started an attempt
+			var zero T
+			return zero, ErrThrottled
+		}
+		return resolvable(ctx)
+	}
+}
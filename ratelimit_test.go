@@ -0,0 +1,101 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var count int
+	v := RateLimit(func(ctx context.Context) (int, error) {
+		count++
+		return count, errors.New("resolve error")
+	}, time.Minute, RateLimitOpts{Now: clock.Now})
+
+	for range 5 {
+		value, err := v(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.Equal(t, 1, value)
+	}
+
+	clock.Add(time.Minute)
+	value, err := v(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 2, value)
+}
+
+func TestRateLimit_Cancelled(t *testing.T) {
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	v := RateLimit(func(ctx context.Context) (int, error) {
+		close(entered)
+		<-release
+		return 1, nil
+	}, time.Minute)
+	defer close(release)
+
+	go func() {
+		_, _ = v(context.Background())
+	}()
+	<-entered
+
+	// waiting for the in-flight resolution is abandoned once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := v(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var count int
+	v := Throttle(func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}, time.Minute, RateLimitOpts{Now: clock.Now})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	for range 3 {
+		value, err := v(ctx)
+		require.ErrorIs(t, err, ErrThrottled)
+		assert.Zero(t, value)
+	}
+
+	clock.Add(time.Minute)
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	t.Run("concurrent", func(t *testing.T) {
+		var count atomic.Int32
+		v := Throttle(func(ctx context.Context) (int, error) {
+			return int(count.Add(1)), nil
+		}, time.Hour)
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = v(ctx)
+			}()
+		}
+		wg.Wait()
+		assert.EqualValues(t, 1, count.Load())
+	})
+}
@@ -0,0 +1,39 @@
+package resolvable
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by Recover when the resolvable panics.
+type PanicError struct {
+	// Value is the value the resolvable panicked with.
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("resolvable: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value the resolvable panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover recovers panics in the resolvable and returns them as a *PanicError with the zero value.
+// A recovered panic is treated like any other error by the decorators around it.
+func Recover[T any](resolvable Ctx[T]) Ctx[T] {
+	return func(ctx context.Context) (v T, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				var zero T
+				v, err = zero, &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return resolvable(ctx)
+	}
+}
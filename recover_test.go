@@ -0,0 +1,63 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no panic", func(t *testing.T) {
+		value, err := Recover(Static(1))(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		v := Recover(func(ctx context.Context) (int, error) {
+			panic("boom")
+		})
+
+		value, err := v(ctx)
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.Contains(t, err.Error(), "boom")
+		assert.Contains(t, err.Error(), "TestRecover")
+		assert.Equal(t, 0, value)
+	})
+
+	t.Run("panic with error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		v := Recover(func(ctx context.Context) (int, error) {
+			panic(errBoom)
+		})
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errBoom)
+	})
+
+	t.Run("option", func(t *testing.T) {
+		var count int
+		v := New(func(ctx context.Context) (int, error) {
+			count++
+			if count == 1 {
+				panic("boom")
+			}
+			return count, nil
+		}, WithRecover(), WithRetry())
+
+		_, err := v(ctx)
+		require.ErrorContains(t, err, "boom")
+
+		// the panic is retried like any other error
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+}
@@ -0,0 +1,81 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Refreshing keeps a value warm by resolving it in the background every interval, so callers never
+// wait for it. Callers receive the latest successfully resolved value. Like Graceful, a failed refresh
+// does not replace the last good value.
+//
+// Until a resolution succeeds, callers resolve the value themselves.
+// The returned CloseFunc stops the background refreshes. Refreshing panics if interval is not positive.
+func Refreshing[T any](resolvable Ctx[T], interval time.Duration) (Ctx[T], CloseFunc) {
+	if interval <= 0 {
+		// rather than in the background, where the caller can't recover it
+		panic("resolvable: non-positive interval for Refreshing")
+	}
+	r := &refreshing[T]{resolvable: resolvable}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.loop(ctx, interval)
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+	}
+	return r.Resolve, stop
+}
+
+type refreshing[T any] struct {
+	resolvable Ctx[T]
+	// latest is the last good value, or nil if it has never resolved successfully.
+	latest atomic.Pointer[T]
+	// mu serializes resolutions.
+	mu sync.Mutex
+}
+
+func (r *refreshing[T]) Resolve(ctx context.Context) (T, error) {
+	if v := r.latest.Load(); v != nil {
+		return *v, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v := r.latest.Load(); v != nil {
+		return *v, nil
+	}
+	return r.refresh(ctx)
+}
+
+func (r *refreshing[T]) loop(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		r.mu.Lock()
+		_, _ = r.refresh(ctx)
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// refresh resolves the value and stores it if successful. mu must be held.
+func (r *refreshing[T]) refresh(ctx context.Context) (T, error) {
+	v, err := r.resolvable(ctx)
+	if err == nil {
+		r.latest.Store(&v)
+	}
+	return v, err
+}
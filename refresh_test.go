@@ -0,0 +1,83 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshing(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count   atomic.Int32
+		failing atomic.Bool
+	)
+	v, stop := Refreshing(func(ctx context.Context) (int, error) {
+		n := int(count.Add(1))
+		if failing.Load() {
+			return n, errors.New("resolve error")
+		}
+		return n, nil
+	}, 5*time.Millisecond)
+	defer stop()
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, value, 1)
+
+	// the value is refreshed in the background
+	require.Eventually(t, func() bool {
+		value, err := v(ctx)
+		return err == nil && value >= 3
+	}, time.Second, time.Millisecond)
+
+	// failed refreshes keep the last good value
+	failing.Store(true)
+	waitRefreshes := func() {
+		calls := count.Load()
+		require.Eventually(t, func() bool {
+			return count.Load() >= calls+2
+		}, time.Second, time.Millisecond)
+	}
+	waitRefreshes()
+	last, err := v(ctx)
+	require.NoError(t, err)
+	waitRefreshes()
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last, value)
+
+	// no more refreshes once stopped
+	stop()
+	calls := count.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, calls, count.Load())
+
+	// closing again is safe
+	require.NoError(t, stop.Close())
+}
+
+func TestRefreshing_NeverResolved(t *testing.T) {
+	ctx := context.Background()
+	v, stop := Refreshing(Ctx[int](func(ctx context.Context) (int, error) {
+		return 0, errors.New("resolve error")
+	}), time.Hour)
+	defer stop()
+
+	// callers resolve themselves until a value is available
+	_, err := v(ctx)
+	require.EqualError(t, err, "resolve error")
+}
+
+func TestRefreshing_InvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		assert.PanicsWithValue(t, "resolvable: non-positive interval for Refreshing", func() {
+			Refreshing(Static(1), interval)
+		})
+	}
+}
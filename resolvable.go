@@ -2,7 +2,11 @@ package resolvable
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,13 +30,38 @@ func (v Ctx[T]) WithBackgroundContext() V[T] {
 	}
 }
 
+// ResolveTimeout resolves the value with a context derived from parent that times out after d.
+// Unlike Timeout, the timeout only applies to this call.
+func (v Ctx[T]) ResolveTimeout(parent context.Context, d time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+	return v(ctx)
+}
+
+// Logger logs diagnostic messages.
+type Logger interface {
+	Debugf(format string, args ...any)
+}
+
 type options struct {
-	once     bool
-	retry    bool
-	graceful bool
-	expiry   time.Duration
-	now      func() time.Time
-	safe     bool
+	once          bool
+	retry         bool
+	retryOpts     RetryOpts
+	jitter        float64
+	graceful      bool
+	expiry        time.Duration
+	now           func() time.Time
+	safe          bool
+	logger        Logger
+	timeout       time.Duration
+	ctxTimeout    time.Duration
+	onError       func(ctx context.Context, err error)
+	onResolve     func(ctx context.Context, d time.Duration, fromCache bool)
+	metrics       Metrics
+	name          string
+	recoverPanics bool
+	// validator is a func(T) error, stored untyped since options are not generic.
+	validator any
 }
 
 type Option func(*options)
@@ -52,6 +81,34 @@ func WithRetry() Option {
 	}
 }
 
+// WithRetryOpts marks the value as retryable on error and configures how retries are performed.
+// It replaces any retry options set by previous options.
+func WithRetryOpts(opts RetryOpts) Option {
+	return func(o *options) {
+		o.retry = true
+		o.retryOpts = opts
+	}
+}
+
+// WithRetryable marks the value as retryable on errors for which fn returns true.
+// Other errors are cached like successful values.
+func WithRetryable(fn func(error) bool) Option {
+	return func(o *options) {
+		o.retry = true
+		o.retryOpts.RetryableErrors = fn
+	}
+}
+
+// WithJitter randomizes the retry backoff by ±factor, see JitterBackOff. It applies to the backoff set
+// by WithRetryOpts, and has no effect unless retries are enabled. The default policy retries immediately,
+// which jitter can't randomize, so NewE returns an error if no backoff is set.
+// A backoff that is already a JitterBackOff is jittered again.
+func WithJitter(factor float64) Option {
+	return func(o *options) {
+		o.jitter = factor
+	}
+}
+
 // WithGraceful allows for graceful degradation.
 // If the resolvable returns an error, the last known good value is returned alongside the new error.
 func WithGraceful() Option {
@@ -77,6 +134,72 @@ func WithNow(now func() time.Time) Option {
 	}
 }
 
+// WithLogger sets a logger for diagnostic messages.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTimeout bounds every resolution of the underlying function to d.
+// Timeouts are treated as errors for the purposes of retries and graceful degradation.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithOnError sets a hook that is called whenever the underlying function fails, including
+// attempts that are retried. The hook runs inline and must not block for long.
+func WithOnError(fn func(ctx context.Context, err error)) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+// WithOnResolve sets a hook that is called whenever a value is returned successfully, with fromCache
+// set for cache hits. For fresh resolutions, d is how long the underlying function took.
+// The hook runs inline and must not block for long.
+func WithOnResolve(fn func(ctx context.Context, d time.Duration, fromCache bool)) Option {
+	return func(o *options) {
+		o.onResolve = fn
+	}
+}
+
+// WithMetrics sets the Metrics that receive cache hits and misses, errors, and how long the
+// underlying function took.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithName labels the resolvable with a name, see Named. The name prefixes log messages, and hooks
+// can read it with NameFrom. Metrics have no context, so tag them with the name when creating them.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithRecover recovers panics in the underlying function and returns them as errors.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recoverPanics = true
+	}
+}
+
+// WithValidator checks successfully resolved values with fn, treating the resolution as failed if
+// it returns an error. See Validate.
+//
+// T must match the type of the resolvable passed to New, otherwise the validator is ignored and NewE
+// returns an error.
+func WithValidator[T any](fn func(T) error) Option {
+	return func(o *options) {
+		o.validator = fn
+	}
+}
+
 // WithUnsafe prevents concurrent access to the resolvable value.
 func WithUnsafe() Option {
 	return func(o *options) {
@@ -84,6 +207,17 @@ func WithUnsafe() Option {
 	}
 }
 
+// WithContextTimeout bounds every call to the resolvable to d, including waiting between retries, by
+// passing down a context with a timeout. Unlike WithTimeout, which bounds every call to the underlying
+// function, it applies once per call, inside WithSafe so that callers don't hold the mutex past the
+// timeout. Waiting for the mutex, or for another caller's resolution of the cache, is not bounded by
+// it. Resolutions of stale values in the background are not bound by it either.
+func WithContextTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.ctxTimeout = d
+	}
+}
+
 // WithSafe allows concurrent access to the resolvable value via a mutex.
 func WithSafe() Option {
 	return func(o *options) {
@@ -91,129 +225,348 @@ func WithSafe() Option {
 	}
 }
 
-// New creates a new resolvable value.
-//
-// Default options are: WithSafe().
-func New[T any](fn Ctx[T], opts ...Option) Ctx[T] {
+// ErrInvalidOptions is returned by NewE when options conflict or are invalid.
+var ErrInvalidOptions = errors.New("resolvable: invalid options")
+
+// NewE is like New, but returns an error wrapping ErrInvalidOptions if the options conflict,
+// e.g. WithOnce() combined with WithCacheTTL(), instead of silently picking one of them.
+func NewE[T any](fn Ctx[T], opts ...Option) (Ctx[T], error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := o.validator.(func(T) error); o.validator != nil && !ok {
+		return nil, fmt.Errorf("%w: WithValidator does not match the resolvable type %T", ErrInvalidOptions, *new(T))
+	}
+	return New(fn, opts...), nil
+}
+
+func newOptions(opts []Option) options {
 	o := options{
 		safe: true,
 	}
 	for _, opt := range opts {
 		opt(&o)
 	}
+	return o
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.once && o.expiry > 0 {
+		errs = append(errs, errors.New("WithOnce and WithCacheTTL are mutually exclusive"))
+	}
+	if o.once && o.retry {
+		errs = append(errs, errors.New("WithOnce and WithRetry are mutually exclusive"))
+	}
+	if o.expiry < 0 {
+		errs = append(errs, errors.New("WithCacheTTL must not be negative"))
+	}
+	if o.timeout < 0 {
+		errs = append(errs, errors.New("WithTimeout must not be negative"))
+	}
+	if o.ctxTimeout < 0 {
+		errs = append(errs, errors.New("WithContextTimeout must not be negative"))
+	}
+	if o.retryOpts.MaxTries < 0 {
+		errs = append(errs, errors.New("RetryOpts.MaxTries must not be negative"))
+	}
+	if o.jitter < 0 {
+		errs = append(errs, errors.New("WithJitter must not be negative"))
+	}
+	if o.jitter > 0 && o.retryOpts.Backoff == nil {
+		errs = append(errs, errors.New("WithJitter requires a Backoff set with WithRetryOpts"))
+	}
+	if o.retryOpts.AttemptTimeout < 0 {
+		errs = append(errs, errors.New("RetryOpts.AttemptTimeout must not be negative"))
+	}
+	if o.retryOpts.MaxElapsedTime < 0 {
+		errs = append(errs, errors.New("RetryOpts.MaxElapsedTime must not be negative"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidOptions, errors.Join(errs...))
+	}
+	return nil
+}
+
+// New creates a new resolvable value.
+// Conflicting options are resolved on a best-effort basis, use NewE to reject them instead.
+//
+// Default options are: WithSafe().
+func New[T any](fn Ctx[T], opts ...Option) Ctx[T] {
+	o := newOptions(opts)
 
 	var v Ctx[T] = fn
 
+	if o.recoverPanics {
+		v = Recover(v)
+	}
+
+	if o.timeout > 0 {
+		v = Timeout(v, o.timeout)
+	}
+
+	if validator, ok := o.validator.(func(T) error); ok {
+		v = Validate(v, validator)
+	}
+
+	if o.onError != nil {
+		v = onError(v, o.onError)
+	}
+
 	if o.graceful {
 		v = Graceful(v)
 	}
 
-	if o.expiry > 0 {
+	if o.retry && o.jitter > 0 && o.retryOpts.Backoff != nil {
+		o.retryOpts.Backoff = NewJitterBackOff(o.retryOpts.backoff(), o.jitter)
+	}
+
+	// a TTL takes precedence over retries, which take precedence over once
+	cached := o.expiry > 0 || o.retry || o.once
+	if cached {
 		v = Cache(v, CacheOpts{
-			Expiry: o.expiry,
-			Retry:  o.retry,
-			Now:    o.now,
+			Expiry:    o.expiry,
+			Retry:     o.retry,
+			RetryOpts: o.retryOpts,
+			Now:       o.now,
+			Logger:    o.logger,
+			OnResolve: o.onResolve,
+			Metrics:   o.metrics,
+			Name:      o.name,
 		})
-	} else if o.retry {
-		v = Retry(v)
-	} else if o.once {
-		v = Once(v)
+	} else {
+		if o.metrics != nil {
+			v = metered(v, o.metrics)
+		}
+		if o.onResolve != nil {
+			v = onResolve(v, o.onResolve)
+		}
+	}
+
+	if o.ctxTimeout > 0 {
+		v = withContextTimeout(v, o.ctxTimeout)
 	}
 
 	// safe concurrent access must go last
-	if o.safe {
+	// Cache already guards itself, and lets cache hits through without waiting for each other.
+	if o.safe && !cached {
 		v = Safe(v)
 	}
 
+	// the name is visible to all of the above
+	if o.name != "" {
+		v = Named(v, o.name)
+	}
+
 	return v
 }
 
+// withContextTimeout passes a context that times out after d to every call to the resolvable.
+func withContextTimeout[T any](resolvable Ctx[T], d time.Duration) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return resolvable(ctx)
+	}
+}
+
+// onError calls fn whenever the resolvable fails.
+func onError[T any](resolvable Ctx[T], fn func(ctx context.Context, err error)) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			fn(ctx, err)
+		}
+		return v, err
+	}
+}
+
+// onResolve calls fn whenever the resolvable succeeds.
+func onResolve[T any](resolvable Ctx[T], fn func(ctx context.Context, d time.Duration, fromCache bool)) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		start := time.Now()
+		v, err := resolvable(ctx)
+		if err == nil {
+			fn(ctx, time.Since(start), false)
+		}
+		return v, err
+	}
+}
+
+type GracefulOpts struct {
+	// MaxStaleness is how long the last known good value may be returned for.
+	// Once it is older, errors are returned with the zero value. Zero means forever.
+	MaxStaleness time.Duration
+	// MaxConsecutiveErrors is how many consecutive errors the last known good value may be returned for.
+	// Once exceeded, errors are returned with the zero value. Zero means unlimited.
+	MaxConsecutiveErrors int
+	// Now sets a custom time.Now function.
+	Now func() time.Time
+}
+
+func (o *GracefulOpts) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
 // Graceful allows for graceful degradation.
 // If the resolvable returns an error, the last known good value is returned alongside the new error.
-func Graceful[T any](resolvable Ctx[T]) Ctx[T] {
+// An optional GracefulOpts may be passed to limit how long the last known good value is returned for.
+//
+// Graceful is safe for concurrent use if the resolvable is.
+func Graceful[T any](resolvable Ctx[T], opts ...GracefulOpts) Ctx[T] {
+	var o GracefulOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return TypedGraceful(resolvable, TypedGracefulOpts[T]{GracefulOpts: o})
+}
+
+// TypedGracefulOpts extends GracefulOpts with options that depend on the type of the value.
+type TypedGracefulOpts[T any] struct {
+	GracefulOpts
+	// OnChange is called when a successfully resolved value differs from the last known good value,
+	// e.g. to react to configuration changes. It is not called for the first value.
+	// It runs inline and must not block for long.
+	OnChange func(old, new T)
+	// Equal reports whether two values are equal for OnChange. Defaults to reflect.DeepEqual, which
+	// may be slow for large values.
+	Equal func(a, b T) bool
+}
+
+func (o *TypedGracefulOpts[T]) equal(a, b T) bool {
+	if o.Equal != nil {
+		return o.Equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// TypedGraceful is like Graceful, with options that depend on the type of the value.
+func TypedGraceful[T any](resolvable Ctx[T], o TypedGracefulOpts[T]) Ctx[T] {
+	type good struct {
+		value      T
+		resolvedAt time.Time
+	}
 	var (
-		lastGood T
-		hasValue bool
+		lastGood atomic.Pointer[good]
+		failures atomic.Int64
 	)
 	return func(ctx context.Context) (T, error) {
-		var err error
 		v, err := resolvable(ctx)
-		if err != nil && hasValue {
+		if err != nil {
+			n := failures.Add(1)
+			last := lastGood.Load()
+			if last == nil {
+				return v, err
+			}
+			if (o.MaxStaleness > 0 && o.now().Sub(last.resolvedAt) > o.MaxStaleness) ||
+				(o.MaxConsecutiveErrors > 0 && n > int64(o.MaxConsecutiveErrors)) {
+				// stop masking sustained failures
+				var zero T
+				return zero, err
+			}
 			// return the last known good value with the current error
-			return lastGood, err
+			return last.value, err
 		}
 		// persist the new value
-		lastGood = v
-		hasValue = true
-		return lastGood, err
+		failures.Store(0)
+		last := lastGood.Swap(&good{value: v, resolvedAt: o.now()})
+		if o.OnChange != nil && last != nil && !o.equal(last.value, v) {
+			o.OnChange(last.value, v)
+		}
+		return v, err
 	}
 }
 
-// Retry will attempt to resolve the value until it succeeds, and then it is cached forever.
-func Retry[T any](resolvable Ctx[T]) Ctx[T] {
-	return Cache(resolvable, CacheOpts{
-		Retry: true,
-	})
-}
-
-// Once will resolve the value once and then return the value forever regardless of errors.
-func Once[T any](resolvable Ctx[T]) Ctx[T] {
-	return Cache(resolvable, CacheOpts{})
-}
-
-type CacheOpts struct {
-	// Expiry is the duration after which the value is considered expired.
-	Expiry time.Duration
-	// Retry indicates whether to retry the resolvable if it returns an error.
-	Retry bool
-	// Now sets a custom time.Now function.
+// RetryOpts configures how a resolvable is retried on error.
+type RetryOpts struct {
+	// Backoff determines how long to wait before retrying after an error.
+	// Returning BackOffStop stops retrying, and the last error is cached until the value expires,
+	// wrapped with ErrRetriesExhausted.
+	// Defaults to retrying immediately on the next call. Caches use a clone of it if it is a
+	// CloneableBackOff.
+	Backoff BackOff
+	// MaxTries is the maximum number of consecutive attempts before giving up.
+	// Once exhausted, the last error is cached until the value expires, wrapped with ErrRetriesExhausted.
+	// Zero means unlimited.
+	MaxTries int
+	// RetryableErrors reports whether an error should be retried.
+	// Other errors are cached until the value expires. Defaults to retrying all errors.
+	RetryableErrors func(error) bool
+	// MaxElapsedTime is how long to keep retrying after the first failed attempt before giving up.
+	// Once exceeded, the last error is cached until the value expires, wrapped with ErrRetriesExhausted.
+	// It is independent of MaxTries, whichever is reached first stops retrying. Zero means unlimited.
+	MaxElapsedTime time.Duration
+	// AttemptTimeout bounds every attempt to the given duration, see Timeout. An attempt that times out
+	// is retried like any other error. Zero means no timeout.
+	AttemptTimeout time.Duration
+	// Now sets a custom time.Now function for RetryLoop. Caches use CacheOpts.Now instead.
 	Now func() time.Time
+	// Metrics receives the errors and durations of every attempt of RetryLoop. Caches use
+	// CacheOpts.Metrics instead.
+	Metrics Metrics
 }
 
-func (o *CacheOpts) now() time.Time {
+func (o *RetryOpts) now() time.Time {
 	if o.Now != nil {
 		return o.Now()
 	}
 	return time.Now()
 }
 
-// Cache is a wrapper around a resolvable value that allows for expiry.
-func Cache[T any](resolvable Ctx[T], opts CacheOpts) Ctx[T] {
-	e := &expirable[T]{resolvable: resolvable, CacheOpts: opts}
-	return e.Resolve
+// exceeded reports whether retrying should stop after tries attempts, the first of which failed at
+// firstFailure.
+func (o *RetryOpts) exceeded(tries int, firstFailure, now time.Time) bool {
+	return (o.MaxTries > 0 && tries >= o.MaxTries) ||
+		(o.MaxElapsedTime > 0 && now.Sub(firstFailure) > o.MaxElapsedTime)
 }
 
-type expirable[T any] struct {
-	CacheOpts
-	resolvable Ctx[T]
-	resolvedAt time.Time
-	value      T
-	err        error
+func (o *RetryOpts) backoff() BackOff {
+	if o.Backoff != nil {
+		return o.Backoff
+	}
+	return zeroBackoff{}
 }
 
-func (e *expirable[T]) Resolve(ctx context.Context) (T, error) {
-	if e.expired() {
-		e.value, e.err = e.resolvable(ctx)
-		if e.err == nil || !e.Retry {
-			// reset the expiry timer if there is no error or we are not retrying on errors
-			e.resolvedAt = e.now()
-		}
+// attempt returns the resolvable bounded by AttemptTimeout.
+func attempt[T any](resolvable Ctx[T], o RetryOpts) Ctx[T] {
+	if o.AttemptTimeout > 0 {
+		return Timeout(resolvable, o.AttemptTimeout)
 	}
-	return e.value, e.err
+	return resolvable
 }
 
-func (e *expirable[T]) expired() bool {
-	if e.resolvedAt.IsZero() {
-		// if we have never resolved, pretend it is expired
-		return true
-	}
+func (o *RetryOpts) retryable(err error) bool {
+	return o.RetryableErrors == nil || o.RetryableErrors(err)
+}
 
-	if e.Expiry <= 0 {
-		// cache forever
-		return false
+// Retry will attempt to resolve the value until it succeeds, and then it is cached forever.
+// An optional RetryOpts may be passed to configure retries.
+func Retry[T any](resolvable Ctx[T], opts ...RetryOpts) Ctx[T] {
+	var retryOpts RetryOpts
+	if len(opts) > 0 {
+		retryOpts = opts[0]
 	}
+	return Cache(resolvable, CacheOpts{
+		Retry:     true,
+		RetryOpts: retryOpts,
+	})
+}
 
-	return e.now().Sub(e.resolvedAt) >= e.Expiry
+// Once will resolve the value once and then return the value forever regardless of errors.
+// Use OnceRetry to resolve again after errors.
+func Once[T any](resolvable Ctx[T]) Ctx[T] {
+	return Cache(resolvable, CacheOpts{})
+}
+
+// OnceRetry is like Once, but errors are not cached: the value is resolved again on the next call until
+// it succeeds once, and then it is returned forever. Use it instead of Once for initialization that may
+// fail transiently. It is the same as Retry without RetryOpts.
+func OnceRetry[T any](resolvable Ctx[T]) Ctx[T] {
+	return Retry(resolvable)
 }
 
 // Safe guards a resolvable with a mutex.
@@ -226,9 +579,62 @@ func Safe[T any](resolvable Ctx[T]) Ctx[T] {
 	}
 }
 
+// SafeReadThrough guards a resolvable that keeps its own cached value, like Safe, but lets callers read
+// that value concurrently instead of waiting for each other. fresh is called under a read lock and
+// returns the cached value and whether it can be used. Only if it can't is the write lock taken; fresh
+// is checked again, so that callers that waited for another caller's resolution use its value, and the
+// resolvable is called if it still can't be used.
+//
+// Unlike Safe, where every call waits for the mutex, callers with a usable value never wait for each
+// other, and only wait for a resolution that is in progress. fresh may run concurrently with other calls
+// to fresh, but never with the resolvable. The read lock is released before the write lock is taken,
+// so neither fresh nor the resolvable may call the returned resolvable.
+//
+// Cached is already safe for concurrent use and doesn't need either; New doesn't wrap caches with Safe.
+func SafeReadThrough[T any](resolvable Ctx[T], fresh func() (T, bool)) Ctx[T] {
+	var mu sync.RWMutex
+	return func(ctx context.Context) (T, error) {
+		mu.RLock()
+		value, ok := fresh()
+		mu.RUnlock()
+		if ok {
+			return value, nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if value, ok := fresh(); ok {
+			return value, nil
+		}
+		return resolvable(ctx)
+	}
+}
+
 // Static returns a resolvable value that always returns the same value.
 func Static[T any](value T) Ctx[T] {
 	return func(ctx context.Context) (T, error) {
 		return value, nil
 	}
 }
+
+// StaticError returns a resolvable value that always returns the zero value and err.
+func StaticError[T any](err error) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		var zero T
+		return zero, err
+	}
+}
+
+// Var returns a resolvable value like Static, along with a function to replace the value, e.g. to
+// hot-reload a configuration. Reads and writes are lock-free and safe for concurrent use.
+func Var[T any](initial T) (Ctx[T], func(T)) {
+	var value atomic.Pointer[T]
+	value.Store(&initial)
+	get := func(ctx context.Context) (T, error) {
+		return *value.Load(), nil
+	}
+	set := func(v T) {
+		value.Store(&v)
+	}
+	return get, set
+}
@@ -3,6 +3,9 @@ package resolvable
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -134,6 +137,29 @@ func TestOnce(t *testing.T) {
 	assert.Equal(t, 1, value)
 }
 
+func TestOnceRetry(t *testing.T) {
+	ctx := context.Background()
+	var count int
+	o := OnceRetry(func(ctx context.Context) (int, error) {
+		count++
+		if count < 3 {
+			return 0, errors.New("resolve error")
+		}
+		return count, nil
+	})
+
+	for range 2 {
+		_, err := o(ctx)
+		require.EqualError(t, err, "resolve error")
+	}
+	for range 2 {
+		value, err := o(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+	}
+	assert.Equal(t, 3, count)
+}
+
 func TestTTL(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
@@ -307,3 +333,635 @@ func TestGracefulTTL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 4, value)
 }
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Debugf(format string, args ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	logger := &testLogger{}
+	v := New(func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, WithOnce(), WithLogger(logger))
+
+	_, err := v(ctx)
+	require.NoError(t, err)
+	_, err = v(ctx)
+	require.NoError(t, err)
+
+	// only fresh resolutions are logged
+	require.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], "resolved")
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+		return int(count.Add(1)), nil
+	}), CacheOpts{Expiry: time.Hour})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := v(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, value)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, count.Load())
+}
+
+func TestSafeReadThrough(t *testing.T) {
+	ctx := context.Background()
+	var (
+		value   int
+		ok      bool
+		calls   atomic.Int32
+		started = make(chan struct{}, 1)
+		release = make(chan struct{})
+	)
+	// a cache that is not safe for concurrent use by itself
+	v := SafeReadThrough(func(ctx context.Context) (int, error) {
+		n := int(calls.Add(1))
+		if n > 1 {
+			started <- struct{}{}
+			<-release
+		}
+		value, ok = n, true
+		return value, nil
+	}, func() (int, bool) {
+		return value, ok
+	})
+
+	res, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res)
+
+	// fresh values are read concurrently
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := v(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, res)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, calls.Load())
+
+	// concurrent callers wait for a single resolution and use its value
+	ok = false
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := v(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, res)
+		}()
+	}
+	<-started
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func BenchmarkCacheHit(b *testing.B) {
+	ctx := context.Background()
+	fn := Static(1)
+
+	b.Run("Safe(Cache)", func(b *testing.B) {
+		// a cache guarded by a plain mutex serializes cache hits
+		v := Safe(Cache(fn, CacheOpts{Expiry: time.Hour}))
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = v(ctx)
+			}
+		})
+	})
+
+	b.Run("Cache", func(b *testing.B) {
+		v := Cache(fn, CacheOpts{Expiry: time.Hour})
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = v(ctx)
+			}
+		})
+	})
+}
+
+// ttlCache is a cache that doesn't synchronize itself, like the ones SafeReadThrough guards.
+type ttlCache struct {
+	value     int
+	expiresAt time.Time
+}
+
+func (c *ttlCache) fresh() (int, bool) {
+	return c.value, time.Now().Before(c.expiresAt)
+}
+
+func (c *ttlCache) resolve(ctx context.Context) (int, error) {
+	if value, ok := c.fresh(); ok {
+		return value, nil
+	}
+	c.value, c.expiresAt = 1, time.Now().Add(time.Hour)
+	return c.value, nil
+}
+
+func BenchmarkSafeReadThrough(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("Safe", func(b *testing.B) {
+		// every cache hit waits for the mutex
+		c := &ttlCache{}
+		v := Safe(c.resolve)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = v(ctx)
+			}
+		})
+	})
+
+	b.Run("SafeReadThrough", func(b *testing.B) {
+		// cache hits only take a read lock, and go through concurrently
+		c := &ttlCache{}
+		v := SafeReadThrough(c.resolve, c.fresh)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = v(ctx)
+			}
+		})
+	})
+}
+
+// fakeClock is a clock that is safe to read from background goroutines.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var (
+		count      atomic.Int32
+		resolveErr atomic.Pointer[error]
+		release    = make(chan struct{}, 10)
+	)
+	v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+		n := int(count.Add(1))
+		if n > 1 {
+			<-release
+		}
+		if err := resolveErr.Load(); err != nil {
+			return n, *err
+		}
+		return n, nil
+	}), CacheOpts{
+		Expiry:               time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		Now:                  clock.Now,
+	})
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// expired but within the stale window, every caller gets the stale value immediately
+	clock.Add(time.Minute)
+	for range 10 {
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	}
+
+	// only a single background resolve was started
+	release <- struct{}{}
+	require.Eventually(t, func() bool {
+		value, _ := v(ctx)
+		return value == 2
+	}, time.Second, time.Millisecond)
+	assert.EqualValues(t, 2, count.Load())
+
+	t.Run("background failure", func(t *testing.T) {
+		resolveErr.Store(ptr(errors.New("resolve error")))
+		clock.Add(time.Minute)
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+
+		release <- struct{}{}
+		require.Eventually(t, func() bool {
+			return count.Load() == 3
+		}, time.Second, time.Millisecond)
+
+		// the stale value is served for the rest of the window without resolving again
+		clock.Add(30 * time.Second)
+		value, err = v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+		assert.EqualValues(t, 3, count.Load())
+
+		// once the window elapses, the value is resolved synchronously
+		clock.Add(30 * time.Second)
+		release <- struct{}{}
+		value, err = v(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.Equal(t, 4, value)
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestOnError(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count  int
+		errs   []error
+		record = func(ctx context.Context, err error) {
+			errs = append(errs, err)
+		}
+	)
+	fn := func(ctx context.Context) (int, error) {
+		count++
+		if count < 3 {
+			return 0, fmt.Errorf("attempt %d", count)
+		}
+		return count, nil
+	}
+
+	t.Run("option", func(t *testing.T) {
+		v := New(fn, WithRetry(), WithOnError(record))
+		for range 4 {
+			_, _ = v(ctx)
+		}
+		require.Len(t, errs, 2)
+		assert.EqualError(t, errs[0], "attempt 1")
+		assert.EqualError(t, errs[1], "attempt 2")
+	})
+
+	count, errs = 0, nil
+	t.Run("cache", func(t *testing.T) {
+		v := Cache(fn, CacheOpts{Retry: true, OnError: record})
+		for range 4 {
+			_, _ = v(ctx)
+		}
+		require.Len(t, errs, 2)
+	})
+}
+
+func TestOnResolve(t *testing.T) {
+	ctx := context.Background()
+	type event struct {
+		d         time.Duration
+		fromCache bool
+	}
+	var events []event
+	record := func(ctx context.Context, d time.Duration, fromCache bool) {
+		events = append(events, event{d, fromCache})
+	}
+	fn := func(ctx context.Context) (int, error) {
+		time.Sleep(time.Millisecond)
+		return 1, nil
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		events = nil
+		v := New(fn, WithOnce(), WithOnResolve(record))
+		for range 3 {
+			_, err := v(ctx)
+			require.NoError(t, err)
+		}
+
+		require.Len(t, events, 3)
+		assert.False(t, events[0].fromCache)
+		assert.GreaterOrEqual(t, events[0].d, time.Millisecond)
+		for _, e := range events[1:] {
+			assert.True(t, e.fromCache)
+			assert.Zero(t, e.d)
+		}
+	})
+
+	t.Run("uncached", func(t *testing.T) {
+		events = nil
+		v := New(fn, WithOnResolve(record))
+		for range 2 {
+			_, err := v(ctx)
+			require.NoError(t, err)
+		}
+
+		require.Len(t, events, 2)
+		assert.False(t, events[1].fromCache)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		events = nil
+		v := New(func(ctx context.Context) (int, error) {
+			return 0, errors.New("resolve error")
+		}, WithOnce(), WithOnResolve(record))
+		_, _ = v(ctx)
+		_, _ = v(ctx)
+		assert.Empty(t, events)
+	})
+}
+
+func TestNow_RetryOnly(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var count int
+	v := New(func(ctx context.Context) (int, error) {
+		count++
+		return count, errors.New("try again")
+	},
+		WithRetryOpts(RetryOpts{Backoff: NewConstantBackOff(time.Minute)}),
+		WithNow(func() time.Time { return now }),
+	)
+
+	_, err := v(ctx)
+	require.EqualError(t, err, "try again")
+
+	// the backoff is measured with the injected clock
+	now = now.Add(59 * time.Second)
+	value, err := v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 1, value)
+
+	now = now.Add(time.Second)
+	value, err = v(ctx)
+	require.EqualError(t, err, "try again")
+	assert.Equal(t, 2, value)
+}
+
+func TestGraceful_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	g := Graceful(Ctx[int](func(ctx context.Context) (int, error) {
+		n := count.Add(1)
+		if n%2 == 0 {
+			return 0, errors.New("resolve error")
+		}
+		return int(n), nil
+	}))
+
+	// resolve once so that there is always a last known good value
+	_, err := g(ctx)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, _ := g(ctx)
+			assert.NotZero(t, value)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGraceful_MaxStaleness(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	var (
+		count      int
+		resolveErr error
+	)
+	g := Graceful(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}), GracefulOpts{
+		MaxStaleness: time.Minute,
+		Now:          func() time.Time { return now },
+	})
+
+	value, err := g(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	resolveErr = errors.New("resolve error")
+	now = now.Add(time.Minute)
+	value, err = g(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 1, value) // last known good value
+
+	// the last known good value is too old
+	now = now.Add(time.Second)
+	value, err = g(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 0, value)
+
+	resolveErr = nil
+	value, err = g(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, value)
+}
+
+func TestGraceful_MaxConsecutiveErrors(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count      int
+		resolveErr error
+	)
+	g := Graceful(Ctx[int](func(ctx context.Context) (int, error) {
+		count++
+		return count, resolveErr
+	}), GracefulOpts{MaxConsecutiveErrors: 2})
+
+	value, err := g(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	resolveErr = errors.New("resolve error")
+	for range 2 {
+		value, err = g(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.Equal(t, 1, value) // last known good value
+	}
+
+	// too many consecutive errors
+	value, err = g(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 0, value)
+
+	// a success resets the counter
+	resolveErr = nil
+	value, err = g(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, value)
+
+	resolveErr = errors.New("resolve error")
+	value, err = g(ctx)
+	require.EqualError(t, err, "resolve error")
+	assert.Equal(t, 5, value)
+}
+
+func TestTypedGraceful_OnChange(t *testing.T) {
+	ctx := context.Background()
+	type config struct{ Version int }
+	var (
+		value      = config{Version: 1}
+		resolveErr error
+		changes    [][2]config
+	)
+	g := TypedGraceful(func(ctx context.Context) (config, error) {
+		return value, resolveErr
+	}, TypedGracefulOpts[config]{
+		OnChange: func(old, new config) { changes = append(changes, [2]config{old, new}) },
+	})
+
+	// not called for the first value, nor when it doesn't change
+	_, _ = g(ctx)
+	_, _ = g(ctx)
+	assert.Empty(t, changes)
+
+	value = config{Version: 2}
+	_, _ = g(ctx)
+	assert.Equal(t, [][2]config{{{1}, {2}}}, changes)
+
+	// errors don't change the last known good value
+	resolveErr = errors.New("resolve error")
+	_, _ = g(ctx)
+	resolveErr = nil
+	_, _ = g(ctx)
+	assert.Len(t, changes, 1)
+
+	t.Run("equal", func(t *testing.T) {
+		var version, changed int
+		g := TypedGraceful(func(ctx context.Context) (config, error) {
+			version++
+			return config{Version: version}, nil
+		}, TypedGracefulOpts[config]{
+			OnChange: func(old, new config) { changed++ },
+			// only major versions count
+			Equal: func(a, b config) bool { return a.Version/10 == b.Version/10 },
+		})
+		for range 10 {
+			_, _ = g(ctx)
+		}
+		assert.Equal(t, 1, changed)
+	})
+}
+
+func TestNewE(t *testing.T) {
+	fn := Static(1)
+
+	v, err := NewE(fn, WithCacheTTL(time.Minute), WithRetry())
+	require.NoError(t, err)
+	value, err := v(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	for name, opts := range map[string][]Option{
+		"once and ttl":      {WithOnce(), WithCacheTTL(time.Minute)},
+		"once and retry":    {WithOnce(), WithRetry()},
+		"negative ttl":      {WithCacheTTL(-time.Minute)},
+		"negative timeout":  {WithTimeout(-time.Second)},
+		"negative maxtries": {WithRetryOpts(RetryOpts{MaxTries: -1})},
+		"negative elapsed":  {WithRetryOpts(RetryOpts{MaxElapsedTime: -1})},
+		"negative attempt":  {WithRetryOpts(RetryOpts{AttemptTimeout: -1})},
+		"negative jitter":   {WithRetry(), WithJitter(-0.5)},
+		"jitter no backoff": {WithRetry(), WithJitter(0.5)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewE(fn, opts...)
+			require.ErrorIs(t, err, ErrInvalidOptions)
+			assert.Nil(t, v)
+		})
+	}
+}
+
+func TestStaticError(t *testing.T) {
+	errResolve := errors.New("resolve error")
+	v := StaticError[int](errResolve)
+
+	for range 2 {
+		value, err := v(context.Background())
+		require.ErrorIs(t, err, errResolve)
+		assert.Zero(t, value)
+	}
+}
+
+func TestVar(t *testing.T) {
+	ctx := context.Background()
+	v, set := Var("a")
+
+	value, err := v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+
+	set("b")
+	value, err = v(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+
+	t.Run("concurrent", func(t *testing.T) {
+		v, set := Var(0)
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				set(i)
+			}()
+			go func() {
+				defer wg.Done()
+				_, err := v(ctx)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestWithJitter(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var count int
+	v := New(func(ctx context.Context) (int, error) {
+		count++
+		return count, errors.New("resolve error")
+	},
+		WithJitter(0.5),
+		WithRetryOpts(RetryOpts{Backoff: NewConstantBackOff(10 * time.Second)}),
+		WithNow(clock.Now),
+	)
+
+	_, _ = v(ctx)
+	// the backoff is somewhere between 5 and 15 seconds
+	clock.Add(5*time.Second - time.Nanosecond)
+	_, _ = v(ctx)
+	assert.Equal(t, 1, count)
+	clock.Add(10*time.Second + time.Nanosecond)
+	_, _ = v(ctx)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,22 @@
+package resolvable
+
+// Result is the outcome of a resolution.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Ok returns a successful Result with v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{Value: v}
+}
+
+// Err returns a failed Result with err and the zero value.
+func Err[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// Unwrap returns the value and error of the Result.
+func (r Result[T]) Unwrap() (T, error) {
+	return r.Value, r.Err
+}
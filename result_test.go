@@ -0,0 +1,20 @@
+package resolvable
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	value, err := Ok(1).Unwrap()
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	errResolve := errors.New("resolve error")
+	value, err = Err[int](errResolve).Unwrap()
+	require.ErrorIs(t, err, errResolve)
+	assert.Zero(t, value)
+}
@@ -0,0 +1,105 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRetriesExhausted is wrapped around the last error when retrying gives up because RetryOpts.MaxTries
+// or RetryOpts.MaxElapsedTime was reached or the backoff returned BackOffStop. The last error can still
+// be unwrapped with errors.Is and errors.As. Errors that are not retryable are returned as is.
+var ErrRetriesExhausted = errors.New("resolvable: retries exhausted")
+
+// RetryLoop resolves the value, retrying on error until it succeeds, RetryOpts.MaxTries or
+// RetryOpts.MaxElapsedTime is reached, the backoff returns BackOffStop, or the error is not retryable.
+// Unlike Retry, which retries on subsequent calls, RetryLoop blocks within a single call and sleeps for
+// the backoff between attempts. Nothing is cached.
+//
+// If the context is done while waiting, the context's error is returned. Waits are capped at the
+// context's deadline, and no attempt is made once it has passed.
+// Every call uses its own clone of a CloneableBackOff. Other backoffs are shared between calls, wrap
+// with Safe for concurrent access.
+func RetryLoop[T any](resolvable Ctx[T], opts RetryOpts) Ctx[T] {
+	resolvable = attempt(resolvable, opts)
+	if opts.Metrics != nil {
+		resolvable = metered(resolvable, opts.Metrics)
+	}
+	return func(ctx context.Context) (T, error) {
+		b := cloneBackOff(opts.backoff())
+		b.Reset()
+		var firstFailure time.Time
+		for tries := 1; ; tries++ {
+			v, err := resolvable(ctx)
+			now := opts.now()
+			if info := infoFrom(ctx); info != nil {
+				info.report(ResolveInfo{ResolvedAt: now, Attempts: tries})
+			}
+			if err == nil || !opts.retryable(err) {
+				return v, err
+			}
+			if tries == 1 {
+				firstFailure = now
+			}
+			if opts.exceeded(tries, firstFailure, now) {
+				return v, exhausted(err)
+			}
+
+			wait := b.NextBackOff()
+			if wait == BackOffStop {
+				return v, exhausted(err)
+			}
+			if err := sleep(ctx, wait); err != nil {
+				var zero T
+				return zero, err
+			}
+		}
+	}
+}
+
+// exhausted wraps err with ErrRetriesExhausted, unless a nested retry already did.
+func exhausted(err error) error {
+	if errors.Is(err, ErrRetriesExhausted) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrRetriesExhausted, err)
+}
+
+// sleep waits for d or until the context is done. The wait is capped at the context's deadline, and
+// once the deadline has passed, an error is returned even if the context has not noticed yet.
+func sleep(ctx context.Context, d time.Duration) error {
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		d = min(d, time.Until(deadline))
+	}
+	if d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if hasDeadline && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// RetryN resolves the value like RetryLoop, making up to n attempts with an exponential backoff that
+// starts at initial and doubles after every attempt. The last error is wrapped with
+// ErrRetriesExhausted. Waits add up to at most initial * (2^(n-1) - 1), e.g. 300ms for 3 attempts
+// starting at 100ms, on top of the time the attempts themselves take.
+//
+// RetryN is safe for concurrent use if the resolvable is.
+func RetryN[T any](resolvable Ctx[T], n int, initial time.Duration) Ctx[T] {
+	return RetryLoop(resolvable, RetryOpts{
+		Backoff:  &ExponentialBackOff{InitialInterval: initial, Multiplier: 2},
+		MaxTries: max(n, 1),
+	})
+}
@@ -0,0 +1,290 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryLoop(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("succeeds", func(t *testing.T) {
+		var count int
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			if count < 3 {
+				return 0, errors.New("try again")
+			}
+			return count, nil
+		}, RetryOpts{Backoff: NewConstantBackOff(time.Millisecond)})
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+	})
+
+	t.Run("max tries", func(t *testing.T) {
+		var count int
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("resolve error")
+		}, RetryOpts{MaxTries: 3, Backoff: NewConstantBackOff(time.Millisecond)})
+
+		value, err := v(ctx)
+		require.ErrorIs(t, err, ErrRetriesExhausted)
+		require.EqualError(t, err, "resolvable: retries exhausted: resolve error")
+		assert.Equal(t, 3, value)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		var count int
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("resolve error")
+		}, RetryOpts{Backoff: NewConstantBackOff(time.Hour)})
+
+		start := time.Now()
+		value, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, value)
+		assert.Equal(t, 1, count)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestRetryableErrors(t *testing.T) {
+	ctx := context.Background()
+	errTransient := errors.New("503")
+	errPermanent := errors.New("404")
+	retryable := func(err error) bool {
+		return errors.Is(err, errTransient)
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		var (
+			count      int
+			resolveErr = errTransient
+		)
+		v := New(func(ctx context.Context) (int, error) {
+			count++
+			return count, resolveErr
+		}, WithRetryable(retryable))
+
+		// transient errors are retried
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errTransient)
+		_, err = v(ctx)
+		require.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 2, count)
+
+		// permanent errors are cached
+		resolveErr = errPermanent
+		_, err = v(ctx)
+		require.ErrorIs(t, err, errPermanent)
+		_, err = v(ctx)
+		require.ErrorIs(t, err, errPermanent)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		var count int
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			if count < 3 {
+				return count, errTransient
+			}
+			return count, errPermanent
+		}, RetryOpts{RetryableErrors: retryable})
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errPermanent)
+		require.NotErrorIs(t, err, ErrRetriesExhausted)
+		assert.Equal(t, 3, count)
+	})
+}
+
+func TestRetriesExhausted_Nested(t *testing.T) {
+	errResolve := errors.New("resolve error")
+	v := Retry(RetryLoop(StaticError[int](errResolve), RetryOpts{MaxTries: 2}), RetryOpts{MaxTries: 2})
+
+	for range 3 {
+		_, err := v(context.Background())
+		require.ErrorIs(t, err, errResolve)
+	}
+	_, err := v(context.Background())
+	require.EqualError(t, err, "resolvable: retries exhausted: resolve error")
+}
+
+func TestRetry_MaxElapsedTime(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cache", func(t *testing.T) {
+		clock := newFakeClock()
+		var count int
+		v := Cache(Ctx[int](func(ctx context.Context) (int, error) {
+			count++
+			return count, errors.New("resolve error")
+		}), CacheOpts{
+			Retry:     true,
+			RetryOpts: RetryOpts{MaxElapsedTime: time.Minute},
+			Now:       clock.Now,
+		})
+
+		for range 3 {
+			_, err := v(ctx)
+			require.NotErrorIs(t, err, ErrRetriesExhausted)
+			clock.Add(30 * time.Second)
+		}
+		_, err := v(ctx)
+		require.ErrorIs(t, err, ErrRetriesExhausted)
+		assert.Equal(t, 4, count)
+
+		// the error is cached from now on
+		clock.Add(time.Hour)
+		_, err = v(ctx)
+		require.ErrorIs(t, err, ErrRetriesExhausted)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		clock := newFakeClock()
+		var count int
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count++
+			clock.Add(20 * time.Second)
+			return count, errors.New("resolve error")
+		}, RetryOpts{MaxTries: 10, MaxElapsedTime: time.Minute, Now: clock.Now})
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, ErrRetriesExhausted)
+		// the first failure is at 20s, and more than a minute has elapsed since by the fifth attempt
+		assert.Equal(t, 5, count)
+	})
+}
+
+func TestRetry_AttemptTimeout(t *testing.T) {
+	ctx := context.Background()
+	slowFirst := func(count *atomic.Int32) Ctx[int] {
+		return func(ctx context.Context) (int, error) {
+			n := count.Add(1)
+			if n == 1 {
+				// hang past the attempt timeout
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return int(n), nil
+		}
+	}
+
+	t.Run("loop", func(t *testing.T) {
+		var count atomic.Int32
+		v := RetryLoop(slowFirst(&count), RetryOpts{AttemptTimeout: 10 * time.Millisecond})
+
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		var count atomic.Int32
+		v := Retry(slowFirst(&count), RetryOpts{AttemptTimeout: 10 * time.Millisecond})
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		var count atomic.Int32
+		v := RetryLoop(func(ctx context.Context) (int, error) {
+			count.Add(1)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}, RetryOpts{AttemptTimeout: time.Second, Backoff: NewConstantBackOff(time.Second)})
+
+		start := time.Now()
+		_, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.EqualValues(t, 1, count.Load())
+	})
+}
+
+func TestRetryLoop_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var count int
+	v := RetryLoop(func(ctx context.Context) (int, error) {
+		count++
+		return 0, errors.New("resolve error")
+	}, RetryOpts{Backoff: NewConstantBackOff(time.Hour)})
+
+	start := time.Now()
+	_, err := v(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// the backoff is capped at the deadline rather than sleeping past it
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, count)
+
+	t.Run("passed", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		require.ErrorIs(t, sleep(ctx, 0), context.DeadlineExceeded)
+	})
+}
+
+func TestRetryN(t *testing.T) {
+	ctx := context.Background()
+	errResolve := errors.New("resolve error")
+	fn, count := Counting(StaticError[int](errResolve))
+
+	start := time.Now()
+	_, err := RetryN(fn, 3, time.Millisecond)(ctx)
+	require.ErrorIs(t, err, ErrRetriesExhausted)
+	require.ErrorIs(t, err, errResolve)
+	assert.Equal(t, 3, count())
+	// waited 1ms, then 2ms
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond)
+
+	t.Run("concurrent", func(t *testing.T) {
+		var calls atomic.Int32
+		v := RetryN(func(ctx context.Context) (int, error) {
+			if calls.Add(1)%2 == 1 {
+				return 0, errResolve
+			}
+			return 1, nil
+		}, 2, time.Millisecond)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = v(ctx)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("single attempt", func(t *testing.T) {
+		fn, count := Counting(StaticError[int](errResolve))
+		_, err := RetryN(fn, 0, time.Millisecond)(ctx)
+		require.ErrorIs(t, err, errResolve)
+		assert.Equal(t, 1, count())
+	})
+}
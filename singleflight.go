@@ -0,0 +1,57 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight ensures only one resolution is in flight at a time. Concurrent callers wait for the
+// in-flight resolution and receive its result and error.
+//
+// The resolution runs with a context that is not cancelled along with the caller's, so a cancelled
+// caller returns its context's error without failing the others.
+func SingleFlight[T any](resolvable Ctx[T]) Ctx[T] {
+	var f flight[T]
+	return func(ctx context.Context) (T, error) {
+		return f.do(ctx, resolvable)
+	}
+}
+
+// flight coalesces concurrent calls into a single call.
+type flight[T any] struct {
+	mu   sync.Mutex
+	call *flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do calls fn unless a call is already in flight, and then waits for the result.
+func (f *flight[T]) do(ctx context.Context, fn Ctx[T]) (T, error) {
+	f.mu.Lock()
+	c := f.call
+	if c == nil {
+		c = &flightCall[T]{done: make(chan struct{})}
+		f.call = c
+		go func() {
+			c.value, c.err = fn(detachInfo(context.WithoutCancel(ctx)))
+
+			f.mu.Lock()
+			f.call = nil
+			f.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,98 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleFlight(t *testing.T) {
+	ctx := context.Background()
+	var (
+		count   atomic.Int32
+		release = make(chan struct{})
+	)
+	v := SingleFlight(func(ctx context.Context) (int, error) {
+		n := count.Add(1)
+		<-release
+		return int(n), errors.New("shared error")
+	})
+
+	const callers = 20
+	var (
+		wg      sync.WaitGroup
+		started sync.WaitGroup
+		values  [callers]int
+		errs    [callers]error
+	)
+	started.Add(callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			values[i], errs[i] = v(ctx)
+		}()
+	}
+	started.Wait()
+	// give the callers a chance to join the flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.EqualValues(t, 1, count.Load())
+	for i := range callers {
+		assert.Equal(t, 1, values[i])
+		assert.EqualError(t, errs[i], "shared error")
+	}
+
+	// the next call resolves again
+	value, _ := v(ctx)
+	assert.Equal(t, 2, value)
+}
+
+func TestSingleFlight_CancelledLeader(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		entered = make(chan struct{})
+	)
+	v := SingleFlight(func(ctx context.Context) (int, error) {
+		close(entered)
+		<-release
+		return 1, ctx.Err()
+	})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error)
+	go func() {
+		_, err := v(leaderCtx)
+		leaderDone <- err
+	}()
+	<-entered
+
+	followerDone := make(chan struct{})
+	var (
+		value int
+		err   error
+	)
+	go func() {
+		defer close(followerDone)
+		value, err = v(context.Background())
+	}()
+	// give the follower a chance to join the flight
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-leaderDone, context.Canceled)
+
+	close(release)
+	<-followerDone
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
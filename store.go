@@ -0,0 +1,50 @@
+package resolvable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store persists cached values outside of the cache, e.g. in Redis or a file, so that they survive
+// process restarts. Only successful values are stored.
+type Store[T any] interface {
+	// Get returns the value stored for key and when it expires, or ok set to false if there is none.
+	// A zero expiry means the value never expires.
+	Get(ctx context.Context, key string) (value T, expiry time.Time, ok bool, err error)
+	// Set stores the value for key until expiry. A zero expiry means the value never expires.
+	Set(ctx context.Context, key string, value T, expiry time.Time) error
+}
+
+// MemoryStore is a Store that keeps values in memory, e.g. to share them between caches.
+// It is safe for concurrent use.
+type MemoryStore[T any] struct {
+	mu      sync.Mutex
+	entries map[string]memoryStoreEntry[T]
+}
+
+type memoryStoreEntry[T any] struct {
+	value  T
+	expiry time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{entries: make(map[string]memoryStoreEntry[T])}
+}
+
+// Get returns the value stored for key.
+func (s *MemoryStore[T]) Get(ctx context.Context, key string) (value T, expiry time.Time, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	return e.value, e.expiry, ok, nil
+}
+
+// Set stores the value for key.
+func (s *MemoryStore[T]) Set(ctx context.Context, key string, value T, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryStoreEntry[T]{value: value, expiry: expiry}
+	return nil
+}
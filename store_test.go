@@ -0,0 +1,119 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Store(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	store := NewMemoryStore[int]()
+	var count int
+	fn := func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}
+	opts := TypedCacheOpts[int]{
+		CacheOpts: CacheOpts{Expiry: time.Minute, Now: clock.Now},
+		Store:     store,
+		StoreKey:  "count",
+	}
+
+	value, err := TypedCache(fn, opts)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	stored, expiry, ok, err := store.Get(ctx, "count")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, stored)
+	assert.Equal(t, clock.Now().Add(time.Minute), expiry)
+
+	// a new cache, e.g. after a restart, loads the value from the store until it expires
+	c := NewTypedCache(fn, opts)
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, count)
+
+	clock.Add(time.Minute)
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	// invalidated values are not loaded from the store again
+	c.Invalidate()
+	value, err = c.Resolve(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	t.Run("errors are not stored", func(t *testing.T) {
+		store := NewMemoryStore[int]()
+		v := TypedCache(StaticError[int](errors.New("resolve error")), TypedCacheOpts[int]{Store: store, StoreKey: "err"})
+		_, err := v(ctx)
+		require.Error(t, err)
+
+		_, _, ok, err := store.Get(ctx, "err")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		v := TypedCache(Static(1), TypedCacheOpts[int]{Store: failingStore[int]{}, StoreKey: "key"})
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	})
+}
+
+func TestKeyedCache_Store(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore[string]()
+	var count int
+	fn := func(ctx context.Context, id int) (string, error) {
+		count++
+		return fmt.Sprintf("user %d (%d)", id, count), nil
+	}
+	opts := TypedKeyedCacheOpts[int, string]{
+		TypedCacheOpts: TypedCacheOpts[string]{Store: store},
+		StoreKey: func(id int) string {
+			return fmt.Sprintf("user:%d", id)
+		},
+	}
+
+	value, err := NewTypedKeyedCache(fn, opts).Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (1)", value)
+
+	stored, _, ok, _ := store.Get(ctx, "user:1")
+	assert.True(t, ok)
+	assert.Equal(t, "user 1 (1)", stored)
+
+	c := NewTypedKeyedCache(fn, opts)
+	value, err = c.Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (1)", value)
+
+	c.Invalidate(1)
+	value, err = c.Resolve(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user 1 (2)", value)
+}
+
+// failingStore is a Store that always fails.
+type failingStore[T any] struct{}
+
+func (failingStore[T]) Get(ctx context.Context, key string) (value T, expiry time.Time, ok bool, err error) {
+	return value, expiry, false, errors.New("get error")
+}
+
+func (failingStore[T]) Set(ctx context.Context, key string, value T, expiry time.Time) error {
+	return errors.New("set error")
+}
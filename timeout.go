@@ -0,0 +1,30 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timeout bounds every resolution to d.
+//
+// The resolvable receives a context that is cancelled after d and must honor it. If the resolution
+// overruns, the zero value is returned with an error wrapping context.DeadlineExceeded. If the caller's
+// context is done first, the result of the resolvable is returned as is.
+func Timeout[T any](resolvable Ctx[T], d time.Duration) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, d, errTimedOut)
+		defer cancel()
+
+		v, err := resolvable(ctx)
+		if errors.Is(context.Cause(ctx), errTimedOut) {
+			var zero T
+			return zero, fmt.Errorf("resolvable: timed out after %s: %w", d, context.DeadlineExceeded)
+		}
+		return v, err
+	}
+}
+
+// errTimedOut is the cause of the contexts cancelled by Timeout, to tell them apart from the caller's.
+var errTimedOut = errors.New("resolvable: timed out")
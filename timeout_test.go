@@ -0,0 +1,139 @@
+package resolvable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("in time", func(t *testing.T) {
+		v := Timeout(Static(1), time.Second)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("overrun", func(t *testing.T) {
+		v := Timeout(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 1, ctx.Err()
+		}, 10*time.Millisecond)
+
+		value, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, value)
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		v := Timeout(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 1, ctx.Err()
+		}, time.Hour)
+
+		value, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NotContains(t, err.Error(), "timed out after")
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("context is cancelled", func(t *testing.T) {
+		var inner context.Context
+		v := Timeout(func(ctx context.Context) (int, error) {
+			inner = ctx
+			return 1, nil
+		}, time.Hour)
+
+		_, err := v(ctx)
+		require.NoError(t, err)
+		assert.ErrorIs(t, inner.Err(), context.Canceled)
+	})
+
+	t.Run("option", func(t *testing.T) {
+		var count int
+		v := New(func(ctx context.Context) (int, error) {
+			count++
+			if count == 1 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return count, nil
+		}, WithTimeout(10*time.Millisecond), WithRetry())
+
+		_, err := v(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// the timeout is retried like any other error
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+}
+
+func TestCtx_ResolveTimeout(t *testing.T) {
+	var resolveCtx context.Context
+	v := Ctx[int](func(ctx context.Context) (int, error) {
+		resolveCtx = ctx
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return 1, nil
+		}
+	})
+
+	_, err := v.ResolveTimeout(context.Background(), time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	value, err := v.ResolveTimeout(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	// the context is cancelled once the call returns
+	assert.ErrorIs(t, resolveCtx.Err(), context.Canceled)
+}
+
+func TestWithContextTimeout(t *testing.T) {
+	v := New(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithContextTimeout(10*time.Millisecond))
+
+	// the resolvable is bound by the timeout, and the lock is released once it passes
+	done := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := v(context.Background())
+			done <- err
+		}()
+	}
+	for range 2 {
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the resolvable")
+		}
+	}
+
+	// derived contexts are cancelled once the call returns
+	var resolveCtx context.Context
+	v = New(func(ctx context.Context) (int, error) {
+		resolveCtx = ctx
+		return 1, nil
+	}, WithContextTimeout(time.Hour))
+	_, err := v(context.Background())
+	require.NoError(t, err)
+	_, ok := resolveCtx.Deadline()
+	assert.True(t, ok)
+	assert.ErrorIs(t, resolveCtx.Err(), context.Canceled)
+
+	_, err = NewE(Static(1), WithContextTimeout(-time.Second))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+}
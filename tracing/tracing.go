@@ -0,0 +1,45 @@
+// Package tracing adds OpenTelemetry tracing to resolvables.
+//
+// It is a separate module so that users of the resolvable module do not depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+
+	"github.com/kamaln7/resolvable"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Attributes recorded on resolution spans.
+const (
+	FromCacheKey = attribute.Key("resolvable.from_cache")
+	AttemptsKey  = attribute.Key("resolvable.attempts")
+	NameKey      = attribute.Key("resolvable.name")
+)
+
+// SpanName is the name of resolution spans.
+const SpanName = "resolvable.Resolve"
+
+// Traced creates a span for every resolution with a tracer named tracerName from the global tracer
+// provider. The span is a child of the span in the context, if any, and records whether the value came
+// from a cache, how many attempts it took, and the error.
+func Traced[T any](r resolvable.Ctx[T], tracerName string) resolvable.Ctx[T] {
+	withInfo := resolvable.WithInfo(r)
+	return func(ctx context.Context) (T, error) {
+		ctx, span := otel.Tracer(tracerName).Start(ctx, SpanName)
+		defer span.End()
+
+		v, info, err := withInfo(ctx)
+		span.SetAttributes(FromCacheKey.Bool(info.FromCache), AttemptsKey.Int(info.Attempts))
+		if info.Name != "" {
+			span.SetAttributes(NameKey.String(info.Name))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return v, err
+	}
+}
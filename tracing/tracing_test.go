@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kamaln7/resolvable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	ctx := context.Background()
+
+	var count int
+	v := Traced(resolvable.Once(func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}), "test")
+
+	// the spans are children of the caller's span
+	ctx, parent := provider.Tracer("test").Start(ctx, "parent")
+	for range 2 {
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	for i, fromCache := range []bool{false, true} {
+		span := spans[i]
+		assert.Equal(t, SpanName, span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Contains(t, span.Attributes(), FromCacheKey.Bool(fromCache))
+		assert.Contains(t, span.Attributes(), AttemptsKey.Int(1))
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		errResolve := errors.New("resolve error")
+		_, err := Traced(resolvable.StaticError[int](errResolve), "test")(context.Background())
+		require.ErrorIs(t, err, errResolve)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "resolve error", span.Status().Description)
+		require.Len(t, span.Events(), 1)
+		assert.Contains(t, span.Events()[0].Attributes, attribute.String("exception.message", "resolve error"))
+	})
+
+	t.Run("name", func(t *testing.T) {
+		_, err := Traced(resolvable.New(resolvable.Static(1), resolvable.WithName("config")), "test")(context.Background())
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		assert.Contains(t, spans[len(spans)-1].Attributes(), NameKey.String("config"))
+	})
+}
@@ -0,0 +1,62 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Validate checks successfully resolved values with fn. If fn returns an error, the resolution is
+// treated as failed and the zero value is returned with that error, so that invalid values are
+// retried or degraded like any other error rather than cached.
+func Validate[T any](resolvable Ctx[T], fn func(T) error) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			return v, err
+		}
+		if err := fn(v); err != nil {
+			var zero T
+			return zero, err
+		}
+		return v, nil
+	}
+}
+
+// Filter is the predicate form of Validate: if pred returns false for a successfully resolved value,
+// the zero value is returned with errOnReject. Use Validate instead to return an error that depends on
+// the value.
+func Filter[T any](resolvable Ctx[T], pred func(T) bool, errOnReject error) Ctx[T] {
+	return Validate(resolvable, func(v T) error {
+		if !pred(v) {
+			return errOnReject
+		}
+		return nil
+	})
+}
+
+// ErrTooLarge is returned by MaxSize for values that exceed the maximum size, if onExceed is nil.
+var ErrTooLarge = errors.New("resolvable: value too large")
+
+// MaxSize measures successfully resolved values with size, and replaces those larger than limit with
+// the result of onExceed, e.g. to truncate them or to return an error. If onExceed is nil, the zero
+// value is returned with ErrTooLarge.
+//
+// Place it inside Cache, as in Cache(MaxSize(...), opts), so that oversized values are handled before
+// they are cached and a failure is retried like any other error.
+func MaxSize[T any](resolvable Ctx[T], size func(T) int, limit int, onExceed func(T) (T, error)) Ctx[T] {
+	return func(ctx context.Context) (T, error) {
+		v, err := resolvable(ctx)
+		if err != nil {
+			return v, err
+		}
+		if n := size(v); n > limit {
+			if onExceed != nil {
+				return onExceed(v)
+			}
+			var zero T
+			return zero, fmt.Errorf("%w: %d > %d", ErrTooLarge, n, limit)
+		}
+		return v, nil
+	}
+}
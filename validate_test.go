@@ -0,0 +1,115 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	errEmpty := errors.New("empty")
+	notEmpty := func(s string) error {
+		if s == "" {
+			return errEmpty
+		}
+		return nil
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		value, err := Validate(Static("token"), notEmpty)(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token", value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Validate(Static(""), notEmpty)(ctx)
+		require.ErrorIs(t, err, errEmpty)
+	})
+
+	t.Run("resolve error", func(t *testing.T) {
+		var called bool
+		_, err := Validate(StaticError[string](errors.New("resolve error")), func(s string) error {
+			called = true
+			return nil
+		})(ctx)
+		require.EqualError(t, err, "resolve error")
+		assert.False(t, called)
+	})
+
+	t.Run("option", func(t *testing.T) {
+		var count int
+		v := New(func(ctx context.Context) (string, error) {
+			count++
+			if count == 1 {
+				return "", nil
+			}
+			return "token", nil
+		}, WithValidator(notEmpty), WithRetry())
+
+		// the invalid value is not cached
+		_, err := v(ctx)
+		require.ErrorIs(t, err, errEmpty)
+		value, err := v(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token", value)
+	})
+
+	t.Run("mismatched option", func(t *testing.T) {
+		_, err := NewE(Static(1), WithValidator(notEmpty))
+		require.ErrorIs(t, err, ErrInvalidOptions)
+	})
+}
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+	errNegative := errors.New("negative")
+	positive := func(n int) bool { return n > 0 }
+
+	value, err := Filter(Static(1), positive, errNegative)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	value, err = Filter(Static(-1), positive, errNegative)(ctx)
+	require.ErrorIs(t, err, errNegative)
+	assert.Zero(t, value)
+
+	// errors are returned without calling pred
+	errResolve := errors.New("resolve error")
+	_, err = Filter(StaticError[int](errResolve), func(int) bool {
+		t.Fatal("pred must not be called on error")
+		return true
+	}, errNegative)(ctx)
+	require.ErrorIs(t, err, errResolve)
+}
+
+func TestMaxSize(t *testing.T) {
+	ctx := context.Background()
+	size := func(b []byte) int { return len(b) }
+
+	value, err := MaxSize(Static([]byte("abc")), size, 3, nil)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abc"), value)
+
+	value, err = MaxSize(Static([]byte("abcd")), size, 3, nil)(ctx)
+	require.ErrorIs(t, err, ErrTooLarge)
+	assert.Nil(t, value)
+
+	// truncate
+	value, err = MaxSize(Static([]byte("abcd")), size, 3, func(b []byte) ([]byte, error) {
+		return b[:3], nil
+	})(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abc"), value)
+
+	// errors are passed through without being measured
+	errResolve := errors.New("resolve error")
+	_, err = MaxSize(StaticError[[]byte](errResolve), func([]byte) int {
+		t.Fatal("size must not be called on errors")
+		return 0
+	}, 3, nil)(ctx)
+	require.ErrorIs(t, err, errResolve)
+}
@@ -0,0 +1,81 @@
+package resolvable
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// WatchOpts configures Watch.
+type WatchOpts[T any] struct {
+	// Equal reports whether two values are equal, so that the second one is not sent.
+	// Defaults to reflect.DeepEqual, which may be slow for large values.
+	Equal func(a, b T) bool
+}
+
+func (o *WatchOpts[T]) equal(a, b T) bool {
+	if o.Equal != nil {
+		return o.Equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Watch resolves the value every interval in the background and sends it on the returned channel
+// whenever it differs from the last value sent, as compared by reflect.DeepEqual or WatchOpts.Equal.
+// The first successful value is always sent. Errors are dropped, and the last value stays current until
+// a resolution succeeds.
+//
+// Values are sent as soon as the previous one is received; a slow receiver delays the next resolution.
+// The returned CloseFunc stops the background resolutions and closes the channel. Watch panics if
+// interval is not positive.
+func Watch[T any](resolvable Ctx[T], interval time.Duration, opts ...WatchOpts[T]) (<-chan T, CloseFunc) {
+	if interval <= 0 {
+		// rather than in the background, where the caller can't recover it
+		panic("resolvable: non-positive interval for Watch")
+	}
+	var o WatchOpts[T]
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	ch := make(chan T)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(ch)
+		watch(ctx, resolvable, interval, ch, o.equal)
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+	}
+	return ch, stop
+}
+
+func watch[T any](ctx context.Context, resolvable Ctx[T], interval time.Duration, ch chan<- T, equal func(a, b T) bool) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	var (
+		last T
+		sent bool
+	)
+	for {
+		if v, err := resolvable(ctx); err == nil && (!sent || !equal(last, v)) {
+			select {
+			case ch <- v:
+				last, sent = v, true
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
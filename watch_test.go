@@ -0,0 +1,77 @@
+package resolvable
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	var count atomic.Int32
+	values := []int{1, 1, 2, 2, 2, 3}
+	ch, stop := Watch(func(ctx context.Context) (int, error) {
+		n := int(count.Add(1)) - 1
+		if n == 2 {
+			return 0, errors.New("resolve error")
+		}
+		return values[min(n, len(values)-1)], nil
+	}, time.Millisecond)
+
+	// duplicates and errors are skipped
+	for _, want := range []int{1, 2, 3} {
+		select {
+		case v := <-ch:
+			assert.Equal(t, want, v)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a value")
+		}
+	}
+
+	stop()
+	_, ok := <-ch
+	assert.False(t, ok, "channel is closed once stopped")
+
+	t.Run("slices", func(t *testing.T) {
+		ch, stop := Watch(Static([]int{1}), time.Millisecond)
+		defer stop()
+		assert.Equal(t, []int{1}, <-ch)
+		select {
+		case v := <-ch:
+			t.Fatalf("unexpected value %v", v)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+}
+
+func TestWatch_Equal(t *testing.T) {
+	type config struct {
+		Version int
+		Loaded  time.Time
+	}
+	var count atomic.Int32
+	ch, stop := Watch(func(ctx context.Context) (config, error) {
+		n := int(count.Add(1))
+		return config{Version: n / 3, Loaded: time.Now()}, nil
+	}, time.Millisecond, WatchOpts[config]{
+		Equal: func(a, b config) bool {
+			return a.Version == b.Version
+		},
+	})
+	defer stop()
+
+	for _, want := range []int{0, 1, 2} {
+		assert.Equal(t, want, (<-ch).Version)
+	}
+}
+
+func TestWatch_InvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		assert.PanicsWithValue(t, "resolvable: non-positive interval for Watch", func() {
+			Watch(Static(1), interval)
+		})
+	}
+}